@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// approvalsCmd represents the approvals command
+var (
+	approvalsCmd = &cobra.Command{
+		Use:   "approvals provider [merge-request-id]",
+		Short: "Check if the code owners of the changed files approved a merge request",
+		Long: `Gets the changed files and the approvers of the merge request from the provider,
+failing when the owners of a changed file didn't approve it. Every section of the
+CODEOWNERS file is checked independently:
+  [Section]       requires an approval from the owners of the matching entry
+  [Section][2]    requires 2 approvals
+  ^[Section]      is optional
+Inside GitLab CI the merge request and the project default to CI_MERGE_REQUEST_IID
+and CI_PROJECT_ID. Example:
+codeowners-verifier approvals gitlab 42 --project group/project`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := initProvider(cmd, args[0])
+			if err != nil {
+				log.Fatalf("Could not initialize provider: %s", err)
+			}
+			reader, ok := client.(providers.MergeRequestReader)
+			if !ok {
+				log.Fatalf("Provider %s can't read merge requests", args[0])
+			}
+			id := os.Getenv("CI_MERGE_REQUEST_IID")
+			if len(args) == 2 {
+				id = args[1]
+			}
+			mrID, err := strconv.Atoi(id)
+			if err != nil {
+				log.Fatalf("Invalid merge request id %q", id)
+			}
+			if approvalsProject == "" {
+				log.Fatalf("Missing project, use --project or CI_PROJECT_ID")
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			codeowners, sections, err := readCodeowners(cmd, filename)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+			}
+			mr, err := reader.GetMergeRequest(approvalsProject, mrID)
+			if err != nil {
+				log.Fatalf("Couldn't get merge request: %s", err)
+			}
+			rules := verifier.RequiredApprovals(codeowners, sections, mr.Files)
+			var isMember verifier.MemberChecker
+			if checker, ok := client.(providers.MembershipChecker); ok {
+				isMember = checker.IsMember
+			}
+			if err := verifier.CheckApprovals(rules, mr.Approvers, isMember); err != nil {
+				log.Fatalf("Couldn't check approvals: %s", err)
+			}
+			approved := true
+			for _, rule := range rules {
+				message := fmt.Sprintf("%s line %d: %s %s, %d of %d approvals (%v) for %v",
+					sectionLabel(rule.Section), rule.Rule.Line, rule.Rule.Path, rule.Rule.Owners,
+					len(rule.Approvers), rule.Section.Approvals, rule.Approvers, rule.Files)
+				switch {
+				case rule.Satisfied() && !rule.Section.Optional:
+					log.Info(message)
+				case rule.Section.Optional:
+					log.Infof("%s (optional)", message)
+				default:
+					log.Error(message)
+					approved = false
+				}
+			}
+			if !approved {
+				log.Fatalf("Merge request %d is missing code owner approvals", mrID)
+			}
+			log.Infof("Merge request %d was approved by the code owners", mrID)
+		},
+	}
+	approvalsProject string
+)
+
+// sectionLabel names the section on messages
+func sectionLabel(section *verifier.Section) string {
+	if section.Name == "" {
+		return "Default section"
+	}
+	return fmt.Sprintf("Section [%s]", section.Name)
+}
+
+func init() {
+	rootCmd.AddCommand(approvalsCmd)
+	approvalsCmd.Flags().StringVar(&approvalsProject, "project", os.Getenv("CI_PROJECT_ID"), "Project of the merge request, as ID or path (Defaults to CI_PROJECT_ID env var)")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// auditCmd represents the audit command
+var (
+	auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the ownership of the repository",
+	}
+	stalenessCmd = &cobra.Command{
+		Use:   "staleness [provider]",
+		Short: "Find CODEOWNERS entries whose owners no longer commit to their files",
+		Long: `For every entry of the CODEOWNERS file, compares its owners with the authors of the
+commits on the files it matches from the local git history, warning about the entries
+whose owners didn't commit in the last --months months. Authors are mapped to usernames
+with the --authors YAML file, like on generate:
+  alice@example.com: alice
+  Bob Smith: "@bob"
+When a provider is given, commits from members of owner groups count as well. Example:
+codeowners-verifier audit staleness gitlab --months 6 --authors authors.yaml`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var isMember verifier.MemberChecker
+			if len(args) == 1 {
+				client, err := initProvider(cmd, args[0])
+				if err != nil {
+					log.Fatalf("Could not initialize provider: %s", err)
+				}
+				checker, ok := client.(providers.MembershipChecker)
+				if !ok {
+					log.Fatalf("Provider %s can't check group members", args[0])
+				}
+				isMember = checker.IsMember
+			}
+			var authors git.Authors
+			if authorsFile != "" {
+				var err error
+				if authors, err = git.LoadAuthors(authorsFile); err != nil {
+					log.Fatalf("Couldn't load authors: %s", err)
+				}
+			}
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			codeowners, sections, err := readCodeowners(cmd, filename)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+			}
+			files, err := git.ListFiles(root)
+			if err != nil {
+				log.Fatalf("Couldn't list repository files: %s", err)
+			}
+			commits, err := git.Log(root, "", "")
+			if err != nil {
+				log.Fatalf("Couldn't read git log: %s", err)
+			}
+			cutoff := time.Now().AddDate(0, -months, 0)
+			report, err := verifier.CheckStaleness(codeowners, sections, files, commits, authors, cutoff, isMember)
+			if err != nil {
+				log.Fatalf("Couldn't check staleness: %s", err)
+			}
+			findings := verifier.StalenessFindings(report)
+			verifier.LogFindings(findings)
+			if len(findings) > 0 && failOnStale {
+				log.Fatalf("Found %d stale CODEOWNERS entries", len(findings))
+			}
+			log.Infof("Found %d stale CODEOWNERS entries out of %d", len(findings), len(report))
+		},
+	}
+	months      int
+	failOnStale bool
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(stalenessCmd)
+	stalenessCmd.Flags().IntVar(&months, "months", 6, "Entries are stale when none of their owners committed in this many months")
+	stalenessCmd.Flags().StringVar(&authorsFile, "authors", "", "Path to a YAML file mapping author emails or names to usernames")
+	stalenessCmd.Flags().BoolVar(&failOnStale, "fail", false, "Exit with an error when stale entries are found")
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/reporter"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+var (
+	commentPlatform     string
+	commentProject      string
+	commentMergeRequest int
+)
+
+// Used to find the pull request number on GITHUB_REF, e.g. refs/pull/7/merge
+var githubRefRegex = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// addCommentFlags adds the flags posting the findings of the command as merge request comments
+func addCommentFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&commentPlatform, "comment", "", fmt.Sprintf("Post the findings as a merge request comment on the platform, one of %v", reporter.ListReporters()))
+	cmd.Flags().StringVar(&commentProject, "comment-project", "", "GitLab project or GitHub owner/repository of the merge request (Defaults to CI_PROJECT_ID or GITHUB_REPOSITORY env vars)")
+	cmd.Flags().IntVar(&commentMergeRequest, "comment-merge-request", 0, "Merge request or pull request number (Defaults to CI_MERGE_REQUEST_IID env var or the number on GITHUB_REF)")
+}
+
+// initReporter configures the reporter of the --comment platform from the flags and
+// the CI environment. The token comes from CODEOWNER_COMMENT_TOKEN, GITLAB_TOKEN or GITHUB_TOKEN
+func initReporter() (reporter.Reporter, error) {
+	token := os.Getenv("CODEOWNER_COMMENT_TOKEN")
+	switch commentPlatform {
+	case "gitlab":
+		if token == "" {
+			token = os.Getenv("GITLAB_TOKEN")
+		}
+		r := &reporter.GitLab{
+			Token:        token,
+			BaseURL:      os.Getenv("CI_API_V4_URL"),
+			Project:      commentProject,
+			MergeRequest: commentMergeRequest,
+		}
+		if r.Project == "" {
+			r.Project = os.Getenv("CI_PROJECT_ID")
+		}
+		if r.MergeRequest == 0 {
+			r.MergeRequest, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+		}
+		return r, r.Init()
+	case "github":
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		r := &reporter.GitHub{
+			Token:       token,
+			BaseURL:     os.Getenv("GITHUB_API_URL"),
+			Repository:  commentProject,
+			PullRequest: commentMergeRequest,
+		}
+		if r.Repository == "" {
+			r.Repository = os.Getenv("GITHUB_REPOSITORY")
+		}
+		if match := githubRefRegex.FindStringSubmatch(os.Getenv("GITHUB_REF")); match != nil && r.PullRequest == 0 {
+			r.PullRequest, _ = strconv.Atoi(match[1])
+		}
+		return r, r.Init()
+	}
+	return nil, fmt.Errorf("Invalid platform %s, valid platforms: %v", commentPlatform, reporter.ListReporters())
+}
+
+// reportFindings posts the findings of the command when --comment is set. Failing to
+// post doesn't change the result of the command, so errors are only logged
+func reportFindings(name string, filename string, findings []verifier.Finding) {
+	if commentPlatform == "" {
+		return
+	}
+	r, err := initReporter()
+	if err != nil {
+		log.Errorf("Couldn't post findings: %s", err)
+		return
+	}
+	// the platforms know the file by its path from the repository root
+	path, err := filepath.Abs(filename)
+	if err == nil {
+		if root, err := verifier.FindRepositoryRoot(filepath.Dir(path)); err == nil {
+			if rel, err := filepath.Rel(root, path); err == nil {
+				filename = filepath.ToSlash(rel)
+			}
+		}
+	}
+	if err := r.Report(name, filename, findings); err != nil {
+		log.Errorf("Couldn't post findings: %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// composeCmd represents the compose command
+var (
+	composeCmd = &cobra.Command{
+		Use:   "compose",
+		Short: "Generates the CODEOWNERS file from the fragments of the sub-projects",
+		Long: `Reads the ` + verifier.FragmentFilename + ` files kept next to the code of every sub-project, with
+patterns relative to their directory, and writes the CODEOWNERS file of the repository with
+the patterns rebased onto those directories. The entries of a sub-project take precedence
+over the ones of its parents. Fails when the fragments conflict. Use --check on CI to
+confirm the CODEOWNERS file is up to date. Example:
+codeowners-verifier compose --check`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			fragments, err := verifier.FindFragments(root)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS fragments: %s", err)
+			}
+			if len(fragments) == 0 {
+				log.Fatalf("No %s files found on %s", verifier.FragmentFilename, root)
+			}
+			content, findings, err := verifier.Compose(fragments)
+			if err != nil {
+				log.Fatalf("Couldn't compose CODEOWNERS file: %s", err)
+			}
+			verifier.LogFindings(findings)
+			if verifier.HasErrors(findings) {
+				log.Fatalf("Found %d conflicts between CODEOWNERS fragments", len(findings))
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				filename = filepath.Join(root, "CODEOWNERS")
+			}
+			if checkComposed {
+				current, err := os.ReadFile(filename)
+				if err != nil && !os.IsNotExist(err) {
+					log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+				}
+				if !bytes.Equal(current, []byte(content)) {
+					log.Fatalf("CODEOWNERS file %s is out of date, run compose to update it", filename)
+				}
+				log.Infof("CODEOWNERS file %s is up to date with %d fragments", filename, len(fragments))
+				return
+			}
+			if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+				log.Fatalf("Couldn't write CODEOWNERS file: %s", err)
+			}
+			log.Infof("Wrote %s from %d fragments", filename, len(fragments))
+		},
+	}
+	checkComposed bool
+)
+
+func init() {
+	rootCmd.AddCommand(composeCmd)
+	composeCmd.Flags().BoolVar(&checkComposed, "check", false, "Don't write the CODEOWNERS file, fail when it isn't up to date with the fragments")
+}
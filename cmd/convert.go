@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// convertCmd represents the convert command
+var (
+	convertCmd = &cobra.Command{
+		Use:   "convert [file]",
+		Short: "Translates a CODEOWNERS file between platforms",
+		Long: fmt.Sprintf(`Reads the CODEOWNERS file on the syntax of a platform and writes it on the syntax of another
+one to the standard output, logging a warning for everything that can't be translated
+faithfully. Use --mapping to rename teams and groups, with a YAML file like:
+"@org/team": "@group/subgroup"
+Example:
+codeowners-verifier convert --from github --to gitlab --mapping owners.yaml > CODEOWNERS.new
+Valid platforms: %v`, verifier.ListDialects()),
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := verifier.GetDialect(convertFrom)
+			if err != nil {
+				log.Fatalf("Couldn't load source platform: %s", err)
+			}
+			to, err := verifier.GetDialect(convertTo)
+			if err != nil {
+				log.Fatalf("Couldn't load target platform: %s", err)
+			}
+			conversion := &verifier.Conversion{From: from, To: to}
+			if ownerMapping != "" {
+				conversion.Owners, err = verifier.LoadOwnerMapping(ownerMapping)
+				if err != nil {
+					log.Fatalf("Couldn't load owner mapping: %s", err)
+				}
+			}
+			filename := cmd.Flag(codeowners).Value.String()
+			if len(args) > 0 {
+				filename = args[0]
+			}
+			if filename == "" {
+				// the file is on the source platform locations
+				currentDir, err := os.Getwd()
+				if err != nil {
+					log.Fatalf("Couldn't get current directory: %s", err)
+				}
+				root, err := verifier.FindRepositoryRoot(currentDir)
+				if err != nil {
+					log.Fatalf("Couldn't find repository root: %s", err)
+				}
+				filename, err = verifier.FindCodeownersFile(root, from)
+				if err != nil {
+					log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+				}
+			}
+			file, err := os.Open(filename)
+			if err != nil {
+				log.Fatalf("Couldn't open CODEOWNERS file: %s", err)
+			}
+			defer file.Close()
+			content, findings, err := conversion.Convert(file)
+			if err != nil {
+				log.Fatalf("Couldn't convert CODEOWNERS file: %s", err)
+			}
+			verifier.LogFindings(findings)
+			fmt.Print(content)
+		},
+	}
+	convertFrom  string
+	convertTo    string
+	ownerMapping string
+)
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+	convertCmd.Flags().StringVar(&convertFrom, "from", "github", fmt.Sprintf("Platform of the CODEOWNERS file, one of %v", verifier.ListDialects()))
+	convertCmd.Flags().StringVar(&convertTo, "to", "gitlab", fmt.Sprintf("Platform to convert the CODEOWNERS file to, one of %v", verifier.ListDialects()))
+	convertCmd.Flags().StringVar(&ownerMapping, "mapping", "", "YAML file mapping the owners of the source platform to the ones of the target")
+}
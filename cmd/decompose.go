@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// decomposeCmd represents the decompose command
+var (
+	decomposeCmd = &cobra.Command{
+		Use:   "decompose dir...",
+		Short: "Splits the CODEOWNERS file into fragments for the sub-projects",
+		Long: `Moves the entries of the CODEOWNERS file restricted to each directory to a ` + verifier.FragmentFilename + `
+file on it, with patterns relative to the directory. The remaining entries go to a fragment on
+the repository root. The CODEOWNERS file is kept, run compose to generate it from the
+fragments. Warns about the entries whose precedence changes once composed. Example:
+codeowners-verifier decompose services/api services/web`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			file, err := os.Open(filename)
+			if err != nil {
+				log.Fatalf("Couldn't open CODEOWNERS file: %s", err)
+			}
+			defer file.Close()
+			var dirs []string
+			for _, dir := range args {
+				// directories are relative to the current directory
+				absolute, err := filepath.Abs(dir)
+				if err != nil {
+					log.Fatalf("Couldn't resolve directory %s: %s", dir, err)
+				}
+				relative, err := filepath.Rel(root, absolute)
+				if err != nil {
+					log.Fatalf("Couldn't resolve directory %s: %s", dir, err)
+				}
+				dirs = append(dirs, relative)
+			}
+			fragments, findings, err := verifier.Decompose(file, dirs)
+			if err != nil {
+				log.Fatalf("Couldn't decompose CODEOWNERS file: %s", err)
+			}
+			verifier.LogFindings(findings)
+			for _, fragment := range fragments {
+				path := filepath.Join(root, filepath.FromSlash(fragment.Path()))
+				if _, err := os.Stat(path); err == nil && !overwriteFragments {
+					log.Fatalf("Fragment %s already exists, use --force to overwrite it", fragment.Path())
+				}
+			}
+			for _, fragment := range fragments {
+				path := filepath.Join(root, filepath.FromSlash(fragment.Path()))
+				if err := os.WriteFile(path, []byte(fragment.Content), 0644); err != nil {
+					log.Fatalf("Couldn't write fragment: %s", err)
+				}
+				log.Infof("Wrote %s", fragment.Path())
+			}
+		},
+	}
+	overwriteFragments bool
+)
+
+func init() {
+	rootCmd.AddCommand(decomposeCmd)
+	decomposeCmd.Flags().BoolVar(&overwriteFragments, "force", false, "Overwrite the existing fragments")
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// diffCmd represents the diff command
+var (
+	diffCmd = &cobra.Command{
+		Use:   "diff rev1 rev2",
+		Short: "Show the effect of a CODEOWNERS change between two revisions",
+		Long: `Reads the CODEOWNERS file from both git revisions and compares the owners of every
+file of rev2, summarizing which files changed owners, which became unowned and which
+rules became shadowed by later rules. The output is markdown, ready to be posted as a
+merge request comment, or JSON with --output json. Example:
+codeowners-verifier diff origin/main HEAD`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			d, err := verifier.GetDialect(cmd.Flag(dialect).Value.String())
+			if err != nil {
+				log.Fatalf("Couldn't load dialect: %s", err)
+			}
+			// the CODEOWNERS file may move between revisions, so unless it's set
+			// its location is looked up on each revision
+			filename := cmd.Flag(codeowners).Value.String()
+			if filename != "" {
+				if filename, err = filepath.Abs(filename); err != nil {
+					log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+				}
+				if filename, err = filepath.Rel(root, filename); err != nil {
+					log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+				}
+				filename = filepath.ToSlash(filename)
+			}
+			before, _, err := codeownersAt(root, args[0], filename, d)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file on %s: %s", args[0], err)
+			}
+			after, files, err := codeownersAt(root, args[1], filename, d)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file on %s: %s", args[1], err)
+			}
+			diff := verifier.DiffOwnership(before, after, files)
+			switch diffOutput {
+			case "json":
+				content, err := json.MarshalIndent(diff, "", "  ")
+				if err != nil {
+					log.Fatalf("Couldn't encode diff: %s", err)
+				}
+				fmt.Println(string(content))
+			case "markdown":
+				fmt.Print(diff.Markdown(fmt.Sprintf("CODEOWNERS changes between `%s` and `%s`", args[0], args[1])))
+			default:
+				log.Fatalf("Invalid output %q, use markdown or json", diffOutput)
+			}
+		},
+	}
+	diffOutput string
+)
+
+// codeownersAt returns the CODEOWNERS entries and the files of the repository on the revision,
+// without entries when the CODEOWNERS file doesn't exist on it. When filename is empty the
+// file is looked up on the locations of the dialect
+func codeownersAt(root string, rev string, filename string, d *verifier.Dialect) ([]*verifier.CodeOwner, []string, error) {
+	files, err := git.ListFilesAt(root, rev)
+	if err != nil {
+		return nil, nil, err
+	}
+	if filename == "" {
+		if filename, err = verifier.FindCodeownersFileIn(files, d); err != nil {
+			log.Warnf("%s on %s", err, rev)
+			return nil, files, nil
+		}
+	}
+	found := false
+	for _, file := range files {
+		found = found || file == filename
+	}
+	if !found {
+		log.Warnf("%s doesn't exist on %s", filename, rev)
+		return nil, files, nil
+	}
+	content, err := git.Show(root, rev, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	codeowners, _, err := verifier.ParseCodeownersWithDialect(strings.NewReader(content), d)
+	return codeowners, files, err
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "markdown", "Output format: markdown or json")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// exportCmd represents the export command
+var (
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the ownership of the repository",
+	}
+	exportGraphCmd = &cobra.Command{
+		Use:   "graph",
+		Short: "Export the directory tree annotated with its owners",
+		Long: fmt.Sprintf(`Matches every file tracked on the repository with the CODEOWNERS file and writes the
+directory tree annotated with the effective owners. Directories whose files all have
+the same owners are collapsed, use --depth to stop expanding deeper directories. Example:
+codeowners-verifier export graph --format dot | dot -Tsvg > ownership.svg
+Valid formats: %v`, verifier.ListGraphFormats()),
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			codeowners, _, err := readCodeowners(cmd, filename)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+			}
+			files, err := git.ListFiles(root)
+			if err != nil {
+				log.Fatalf("Couldn't list repository files: %s", err)
+			}
+			graph := verifier.BuildOwnershipGraph(codeowners, files, graphDepth)
+			if err := verifier.WriteGraph(os.Stdout, graph, graphFormat); err != nil {
+				log.Fatalf("Couldn't write the ownership graph: %s", err)
+			}
+		},
+	}
+	graphFormat string
+	graphDepth  int
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportGraphCmd)
+	exportGraphCmd.Flags().StringVarP(&graphFormat, "format", "f", verifier.GraphDOT, fmt.Sprintf("Output format, one of %v", verifier.ListGraphFormats()))
+	exportGraphCmd.Flags().IntVar(&graphDepth, "depth", 0, "Deepest directory level expanded, 0 expands every directory")
+}
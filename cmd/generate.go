@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/generator"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// generateCmd represents the generate command
+var (
+	generateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Draft a CODEOWNERS file from the git history",
+		Long: `Finds the main contributors of the repository and of each directory on the git log,
+or on git blame with --blame, and writes a draft CODEOWNERS file with a comment explaining
+every entry. Contributors need --min-share of the changed lines and --min-commits commits
+on a directory, use --since and --until to limit the history, e.g. --since "1 year ago".
+Authors are written as their email unless mapped to a username on the --authors YAML file:
+  alice@example.com: alice
+  Bob Smith: "@bob"
+Suggested owners can be checked on a provider with --check-provider. Example:
+codeowners-verifier generate --since "2 years ago" --authors authors.yaml --check-provider gitlab > CODEOWNERS`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			opts := generator.Options{
+				Depth:      generateDepth,
+				MinShare:   minShare,
+				MinCommits: minCommits,
+				MaxOwners:  maxOwners,
+			}
+			if authorsFile != "" {
+				if opts.Authors, err = git.LoadAuthors(authorsFile); err != nil {
+					log.Fatalf("Couldn't load authors: %s", err)
+				}
+			}
+			files, err := git.ListFiles(root)
+			if err != nil {
+				log.Fatalf("Couldn't list repository files: %s", err)
+			}
+			var contributions []generator.Contribution
+			if blame {
+				// with --until the lines are blamed as they were on the last commit before it,
+				// otherwise on the working tree
+				rev, tree := "", "HEAD"
+				if until != "" {
+					if rev, err = git.RevisionBefore(root, until); err != nil {
+						log.Fatalf("Couldn't find the revision of --until: %s", err)
+					}
+					if files, err = git.ListFilesAt(root, rev); err != nil {
+						log.Fatalf("Couldn't list repository files: %s", err)
+					}
+					tree = rev
+				}
+				// submodules are listed as files, but git blame fails on them
+				submodules, err := git.ListSubmodules(root, tree)
+				if err != nil {
+					log.Fatalf("Couldn't list repository submodules: %s", err)
+				}
+				skip := make(map[string]bool)
+				for _, submodule := range submodules {
+					skip[submodule] = true
+				}
+				for _, file := range files {
+					if skip[file] {
+						log.Warnf("Skipping submodule %s, its history isn't on this repository", file)
+						continue
+					}
+					lines, err := git.BlameAt(root, rev, file, since)
+					if err != nil {
+						log.Fatalf("Couldn't read git blame: %s", err)
+					}
+					contributions = append(contributions, generator.FromBlame(file, lines)...)
+				}
+			} else {
+				commits, err := git.Log(root, since, until)
+				if err != nil {
+					log.Fatalf("Couldn't read git log: %s", err)
+				}
+				contributions = generator.FromLog(commits)
+			}
+			suggestions := generator.Suggest(contributions, files, opts)
+			if checkProvider != "" {
+				if err := checkSuggestedOwners(cmd, suggestions); err != nil {
+					log.Fatalf("Couldn't check owners on the provider: %s", err)
+				}
+			}
+			if err := generator.Write(os.Stdout, suggestions); err != nil {
+				log.Fatalf("Couldn't write CODEOWNERS draft: %s", err)
+			}
+		},
+	}
+	since         string
+	until         string
+	blame         bool
+	generateDepth int
+	minShare      float64
+	minCommits    int
+	maxOwners     int
+	authorsFile   string
+	checkProvider string
+)
+
+// checkSuggestedOwners marks the owners that aren't users or groups on the --check-provider
+func checkSuggestedOwners(cmd *cobra.Command, suggestions []*generator.Suggestion) error {
+	client, err := initProvider(cmd, checkProvider)
+	if err != nil {
+		return err
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, suggestion := range suggestions {
+		for _, owner := range suggestion.Owners {
+			name := strings.TrimPrefix(owner.Username, "@")
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	owners, err := providers.ResolveOwners(client, names)
+	if err != nil {
+		return err
+	}
+	for _, suggestion := range suggestions {
+		for _, owner := range suggestion.Owners {
+			info := owners[strings.TrimPrefix(owner.Username, "@")]
+			owner.NotFound = !info.User && !info.Group
+			if owner.NotFound {
+				log.Warnf("Owner %s of %s wasn't found on %s", owner.Username, suggestion.Path, checkProvider)
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.Flags().StringVar(&since, "since", "", "Only use the history after this date, any date git accepts E.g: \"1 year ago\", 2023-01-01")
+	generateCmd.Flags().StringVar(&until, "until", "", "Only use the history before this date, any date git accepts. With --blame, blames the last commit before it")
+	generateCmd.Flags().BoolVar(&blame, "blame", false, "Use the authors of the current lines from git blame instead of the lines changed on git log")
+	generateCmd.Flags().IntVar(&generateDepth, "depth", 2, "Deepest directory level to suggest owners for, 0 only suggests the default owners")
+	generateCmd.Flags().Float64Var(&minShare, "min-share", 0.2, "Share of the changed lines of a directory a contributor needs, from 0 to 1")
+	generateCmd.Flags().IntVar(&minCommits, "min-commits", 2, "Commits on a directory a contributor needs")
+	generateCmd.Flags().IntVar(&maxOwners, "max-owners", 3, "Maximum owners suggested for each directory")
+	generateCmd.Flags().StringVar(&authorsFile, "authors", "", "Path to a YAML file mapping author emails or names to usernames")
+	generateCmd.Flags().StringVar(&checkProvider, "check-provider", "", "Check the suggested owners exist on this provider")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// reportCmd represents the report command
+var (
+	reportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Report on the ownership of the repository",
+	}
+	reportOwnersCmd = &cobra.Command{
+		Use:   "owners [owner...]",
+		Short: "List what every user or group owns",
+		Long: fmt.Sprintf(`Matches every file tracked on the repository with the CODEOWNERS file, and lists for
+every owner the files, directories and lines it owns, and the rules granting the ownership.
+Pass owners to report only on them. Example:
+codeowners-verifier report owners @group1 --format markdown
+Valid formats: %v`, verifier.ListInventoryFormats()),
+		Run: func(cmd *cobra.Command, args []string) {
+			currentDir, err := os.Getwd()
+			if err != nil {
+				log.Fatalf("Couldn't get current directory: %s", err)
+			}
+			root, err := verifier.FindRepositoryRoot(currentDir)
+			if err != nil {
+				log.Fatalf("Couldn't find repository root: %s", err)
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			codeowners, _, err := readCodeowners(cmd, filename)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+			}
+			files, err := git.ListFiles(root)
+			if err != nil {
+				log.Fatalf("Couldn't list repository files: %s", err)
+			}
+			// submodules are listed as files, but are directories without lines of their own
+			submodules, err := git.ListSubmodules(root, "HEAD")
+			if err != nil {
+				log.Fatalf("Couldn't list repository submodules: %s", err)
+			}
+			skip := make(map[string]bool)
+			for _, submodule := range submodules {
+				skip[submodule] = true
+			}
+			inventories, err := verifier.BuildInventory(codeowners, files, func(file string) (int, error) {
+				if skip[file] {
+					return 0, nil
+				}
+				content, err := os.ReadFile(filepath.Join(root, file))
+				if errors.Is(err, fs.ErrNotExist) {
+					// tracked files deleted from the working tree have no lines left
+					return 0, nil
+				}
+				if err != nil {
+					return 0, err
+				}
+				lines := bytes.Count(content, []byte("\n"))
+				if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+					lines++
+				}
+				return lines, nil
+			})
+			if err != nil {
+				log.Fatalf("Couldn't build the ownership inventory: %s", err)
+			}
+			if len(args) > 0 {
+				var selected []*verifier.Inventory
+				for _, inventory := range inventories {
+					for _, owner := range args {
+						if strings.EqualFold(strings.TrimPrefix(owner, "@"), strings.TrimPrefix(inventory.Owner, "@")) {
+							selected = append(selected, inventory)
+							break
+						}
+					}
+				}
+				inventories = selected
+			}
+			if err := verifier.WriteInventory(os.Stdout, inventories, reportFormat); err != nil {
+				log.Fatalf("Couldn't write the ownership inventory: %s", err)
+			}
+		},
+	}
+	reportFormat string
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportOwnersCmd)
+	reportOwnersCmd.Flags().StringVarP(&reportFormat, "format", "f", verifier.InventoryTable, fmt.Sprintf("Output format, one of %v", verifier.ListInventoryFormats()))
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// reviewersCmd represents the reviewers command
+var (
+	reviewersCmd = &cobra.Command{
+		Use:   "reviewers [path...]",
+		Short: "Suggest the owners to review a change",
+		Long: `For the changed paths, suggests the smallest set of owners that covers every path,
+with the paths each one covers. Entries of a section requiring more than one approval,
+e.g. [Section][2], get as many reviewers. Paths are read from the arguments, from an
+unified diff with --diff, or one per line from stdin. Use --ignore to leave out the author.
+Examples:
+codeowners-verifier reviewers --diff changes.diff --ignore @user1
+git diff --name-only main | codeowners-verifier reviewers --output json`,
+		Run: func(cmd *cobra.Command, args []string) {
+			files := args
+			if len(files) == 0 {
+				content, err := readInput(diffFile)
+				if err != nil {
+					log.Fatalf("Couldn't read changed paths: %s", err)
+				}
+				if diffFile != "" {
+					files = verifier.ChangedFiles(content)
+				} else {
+					for _, line := range strings.Split(content, "\n") {
+						if line = strings.TrimSpace(line); line != "" {
+							files = append(files, line)
+						}
+					}
+				}
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			codeowners, sections, err := readCodeowners(cmd, filename)
+			if err != nil {
+				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
+			}
+			reviewers := verifier.SuggestReviewers(codeowners, sections, files, ignore)
+			switch reviewersOutput {
+			case "json":
+				content, err := json.MarshalIndent(reviewers, "", "  ")
+				if err != nil {
+					log.Fatalf("Couldn't encode reviewers: %s", err)
+				}
+				fmt.Println(string(content))
+			case "text":
+				for _, reviewer := range reviewers.Reviewers {
+					fmt.Printf("%s: %s\n", reviewer.Owner, strings.Join(reviewer.Files, ", "))
+				}
+				if len(reviewers.Uncovered) > 0 {
+					fmt.Printf("Uncovered: %s\n", strings.Join(reviewers.Uncovered, ", "))
+				}
+			default:
+				log.Fatalf("Invalid output %q, use text or json", reviewersOutput)
+			}
+		},
+	}
+	diffFile        string
+	reviewersOutput string
+)
+
+// readInput returns the contents of the file, or of stdin when it's - or empty
+func readInput(filename string) (string, error) {
+	var content []byte
+	var err error
+	if filename == "" || filename == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(filename)
+	}
+	return string(content), err
+}
+
+func init() {
+	rootCmd.AddCommand(reviewersCmd)
+	reviewersCmd.Flags().StringVar(&diffFile, "diff", "", "Path to an unified diff with the changes, - reads it from stdin")
+	reviewersCmd.Flags().StringVarP(&reviewersOutput, "output", "o", "text", "Output format: text or json")
+	reviewersCmd.Flags().StringSliceVarP(&ignore, "ignore", "i", []string{}, "Comma separated list of owners to leave out of the suggestions E.g: @user1,@group1")
+}
@@ -1,17 +1,33 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+	"github.com/topfreegames/codeowners-verifier/pkg/replay"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
 )
 
 var (
 	token      = "token"
 	baseurl    = "base-url"
 	codeowners = "codeowners"
+	dialect    = "dialect"
+
+	providerSettings []string
+	providerStrategy string
+	providerConfig   string
+	recordFile       string
+	replayFile       string
+
+	// openProviders holds the providers to close once the command finishes, e.g. plugin processes
+	openProviders []io.Closer
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,6 +38,12 @@ var rootCmd = &cobra.Command{
 	It verifies the integrity of your CODEOWNERS file based on a predefined provider (currently only GITLAB),
 	You can check if every user and group declared actually exists. You can also check if a file has an CODEOWNER
 	defined, using the --ignore flag to ignore OWNERS.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return initReplay()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		closeProviders()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -40,6 +62,8 @@ func init() {
 	// Only log the warning severity or above.
 	log.SetLevel(log.InfoLevel)
 	cobra.OnInitialize(initConfig)
+	// commands exit through log.Fatal on errors, skipping PersistentPostRun
+	log.RegisterExitHandler(closeProviders)
 	if err := viper.BindEnv(token, "CODEOWNER_PROVIDER_TOKEN"); err != nil {
 		log.Fatal("error initializing viper for env CODEOWNER_PROVIDER_TOKEN")
 	}
@@ -57,10 +81,183 @@ func init() {
 	if err := viper.BindEnv(codeowners, "CODEOWNER_PATH"); err != nil {
 		log.Fatal("error initializing viper for env CODEOWNER_PATH")
 	}
-	rootCmd.PersistentFlags().String(codeowners, viper.GetString(codeowners), "Path to the CODEOWNERS file (Defaults to CODEOWNER_PATH env var)")
+	rootCmd.PersistentFlags().String(codeowners, viper.GetString(codeowners), "Path to the CODEOWNERS file (Defaults to CODEOWNER_PATH env var, or the file discovered for the dialect)")
 	if err := viper.BindPFlag(codeowners, rootCmd.PersistentFlags().Lookup(codeowners)); err != nil {
 		log.Fatal("error binding viper for flag CODEOWNER_PATH")
 	}
+	if err := viper.BindEnv(dialect, "CODEOWNER_DIALECT"); err != nil {
+		log.Fatal("error initializing viper for env CODEOWNER_DIALECT")
+	}
+	viper.SetDefault(dialect, verifier.DefaultDialect)
+	rootCmd.PersistentFlags().String(dialect, viper.GetString(dialect), fmt.Sprintf("CODEOWNERS dialect, one of %v (Defaults to CODEOWNER_DIALECT env var)", verifier.ListDialects()))
+	if err := viper.BindPFlag(dialect, rootCmd.PersistentFlags().Lookup(dialect)); err != nil {
+		log.Fatal("error binding viper for flag CODEOWNER_DIALECT")
+	}
+	rootCmd.PersistentFlags().StringVar(&providerStrategy, "provider-strategy", providers.FirstMatch, fmt.Sprintf("Strategy used when chaining providers, e.g. ldap,gitlab. One of %v", providers.ListStrategies()))
+	rootCmd.PersistentFlags().StringVar(&providerConfig, "provider-config", "", "Path to a YAML file configuring the chained providers, used with the composite provider")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record the provider API responses to this fixture file")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Answer the provider API requests from this fixture file, without network access")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.PersistentFlags().StringArrayVar(&providerSettings, "provider-setting", []string{}, "Provider specific setting as key=value, can be used multiple times. E.g: user-base-dn=ou=people,dc=example,dc=org")
+}
+
+// initReplay wraps the providers HTTP transport when --record or --replay are set
+func initReplay() error {
+	mode, file := replay.ModeRecord, recordFile
+	if replayFile != "" {
+		mode, file = replay.ModeReplay, replayFile
+	}
+	if file == "" {
+		return nil
+	}
+	recorder, err := replay.New(mode, file)
+	if err != nil {
+		return err
+	}
+	providers.WrapTransport = recorder.Wrap
+	return nil
+}
+
+// initProvider initializes the provider with the token, base URL and settings from the flags,
+// it's closed once the command finishes
+func initProvider(cmd *cobra.Command, name string) (providers.Provider, error) {
+	p, err := openProvider(cmd, name)
+	if closer, ok := p.(io.Closer); ok && err == nil {
+		openProviders = append(openProviders, closer)
+	}
+	return p, err
+}
+
+// closeProviders closes the providers opened by initProvider
+func closeProviders() {
+	for _, closer := range openProviders {
+		if err := closer.Close(); err != nil {
+			log.Warnf("Couldn't close provider: %s", err)
+		}
+	}
+	openProviders = nil
+}
+
+// openProvider initializes the provider from the flags. A comma separated list of providers is
+// chained with the --provider-strategy, each one using the CODEOWNER_<PROVIDER>_TOKEN and
+// CODEOWNER_<PROVIDER>_URL env vars when they are set, and the composite provider is
+// configured from the --provider-config file
+func openProvider(cmd *cobra.Command, name string) (providers.Provider, error) {
+	if name == "composite" {
+		if providerConfig == "" {
+			return nil, fmt.Errorf("composite provider requires --provider-config")
+		}
+		return providers.LoadComposite(providerConfig)
+	}
+	settings := providers.Settings{}
+	for _, setting := range providerSettings {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return nil, fmt.Errorf("Invalid provider setting %s, expected key=value", setting)
+		}
+		settings[key] = value
+	}
+	if strings.Contains(name, ",") {
+		members := strings.Split(name, ",")
+		// each member gets the settings it accepts, but every setting must be accepted by one of them
+		memberSettings := make(map[string]providers.Settings)
+		used := make(map[string]bool)
+		for _, member := range members {
+			names, ok := providers.SettingNames(member)
+			if !ok {
+				memberSettings[member] = settings
+				for key := range settings {
+					used[key] = true
+				}
+				continue
+			}
+			memberSettings[member] = providers.Settings{}
+			for _, key := range names {
+				if value, ok := settings[key]; ok {
+					memberSettings[member][key] = value
+					used[key] = true
+				}
+			}
+		}
+		for _, setting := range providerSettings {
+			if key, _, _ := strings.Cut(setting, "="); !used[key] {
+				return nil, fmt.Errorf("Unknown provider setting %s for providers %s", key, name)
+			}
+		}
+		composite := &providers.Composite{Strategy: providerStrategy}
+		for _, member := range members {
+			p, err := newProvider(cmd, member, memberSettings[member])
+			if err != nil {
+				composite.Close()
+				return nil, fmt.Errorf("%s: %s", member, err)
+			}
+			composite.Providers = append(composite.Providers, p)
+		}
+		if err := composite.Init(); err != nil {
+			composite.Close()
+			return nil, err
+		}
+		return composite, nil
+	}
+	return newProvider(cmd, name, settings)
+}
+
+// newProvider initializes a single provider with the token and base URL from the flags or env vars
+func newProvider(cmd *cobra.Command, name string, settings providers.Settings) (providers.Provider, error) {
+	providerToken := cmd.Flag(token).Value.String()
+	if value, ok := os.LookupEnv("CODEOWNER_" + strings.ToUpper(name) + "_TOKEN"); ok {
+		providerToken = value
+	}
+	if providerToken == "" && replayFile != "" {
+		// the token isn't needed to answer from the fixture
+		providerToken = "replay"
+	}
+	providerURL := cmd.Flag(baseurl).Value.String()
+	if value, ok := os.LookupEnv("CODEOWNER_" + strings.ToUpper(name) + "_URL"); ok {
+		providerURL = value
+	}
+	return providers.InitProviderWithSettings(name, providerToken, providerURL, settings)
+}
+
+// codeownersFile returns the CODEOWNERS path from the flags,
+// discovering it from the repository root when it isn't set
+func codeownersFile(cmd *cobra.Command) (string, error) {
+	if path := cmd.Flag(codeowners).Value.String(); path != "" {
+		return path, nil
+	}
+	d, err := verifier.GetDialect(cmd.Flag(dialect).Value.String())
+	if err != nil {
+		return "", err
+	}
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	root, err := verifier.FindRepositoryRoot(currentDir)
+	if err != nil {
+		return "", err
+	}
+	return verifier.FindCodeownersFile(root, d)
+}
+
+// withProvidersHelp lists the valid providers on the help of the command, plugins are
+// looked up on PATH only when the help is shown instead of when the CLI starts
+func withProvidersHelp(cmd *cobra.Command) {
+	help := cmd.HelpFunc()
+	cmd.SetHelpFunc(func(c *cobra.Command, args []string) {
+		c.Long += fmt.Sprintf("\nValid providers: %v", providers.ListProviders())
+		help(c, args)
+	})
+}
+
+// readCodeowners reads the entries and the section headers of the CODEOWNERS file
+// using the dialect from the flags
+func readCodeowners(cmd *cobra.Command, filename string) ([]*verifier.CodeOwner, []*verifier.Section, error) {
+	d, err := verifier.GetDialect(cmd.Flag(dialect).Value.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return verifier.ReadCodeownersFileWithDialect(filename, d)
 }
 
 // initConfig reads in config file and ENV variables if set.
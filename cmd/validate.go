@@ -1,41 +1,148 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/topfreegames/codeowners-verifier/pkg/policy"
 	"github.com/topfreegames/codeowners-verifier/pkg/providers"
 	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
 )
 
 // validateCmd represents the validate command
-var validateCmd = &cobra.Command{
-	Use:   "validate provider",
-	Short: "Validate the integrity of a CODEOWNERS file",
-	Long: fmt.Sprintf(`Check if every entry on the CODEOWNERS file exists on the provider.
-Valid providers: %v`, providers.ListProviders()),
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		client, err := providers.InitProvider(args[0], cmd.Flag(token).Value.String(), cmd.Flag(baseurl).Value.String())
-		if err != nil {
-			log.Fatalf("Could not initialize provider: %s", err)
-		}
-		valid, err := verifier.ValidateCodeownerFile(client, cmd.Flag(codeowners).Value.String())
-		if err != nil {
-			log.Fatalf("Error reading CODEOWNERS file contents: %s", err)
-		}
-		if valid {
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate provider",
+		Short: "Validate the integrity of a CODEOWNERS file",
+		Long: `Check if every entry on the CODEOWNERS file exists on the provider,
+and if the file is within the limits of the platform for the chosen dialect.
+Limits can be overridden with the flag --limit, e.g. --limit owner-count=20,file-size=0
+Duplicated patterns and owners are reported as warnings, use --fix to remove them.
+Organizational rules can be enforced with a YAML policy file passed on --policy.
+Findings can be suppressed with directive comments on the CODEOWNERS file:
+  # codeowners-verifier:ignore path-not-found       (on the line before a rule)
+  # codeowners-verifier:ignore-file owner-count     (anywhere on the file)
+Known findings listed on the --baseline file are suppressed, use --update-baseline
+to regenerate it with the current findings.
+Findings can be posted as a merge request comment with --comment gitlab or --comment github.
+Providers can be chained as a comma separated list, e.g. ldap,gitlab, see --provider-strategy,
+or configured on a file with the composite provider and --provider-config.
+Providers are builtin or plugins named ` + providers.PluginPrefix + `<provider> found on PATH.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := initProvider(cmd, args[0])
+			if err != nil {
+				log.Fatalf("Could not initialize provider: %s", err)
+			}
+			d, err := verifier.GetDialect(cmd.Flag(dialect).Value.String())
+			if err != nil {
+				log.Fatalf("Could not load dialect: %s", err)
+			}
+			d.Limits, err = d.Limits.Override(limits)
+			if err != nil {
+				log.Fatalf("Could not override limits: %s", err)
+			}
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			if fix {
+				fixed, err := verifier.FixDuplicates(filename, d)
+				if err != nil {
+					log.Fatalf("Couldn't fix CODEOWNERS file: %s", err)
+				}
+				for _, f := range fixed {
+					log.Infof("Fixed: %s", f.Message)
+				}
+			}
+			findings, err := verifier.CheckLimits(filename, d)
+			if err != nil {
+				log.Fatalf("Error reading CODEOWNERS file contents: %s", err)
+			}
+			codeowners, sections, err := verifier.ReadCodeownersFileWithDialect(filename, d)
+			if err != nil {
+				log.Fatalf("Error reading CODEOWNERS file contents: %s", err)
+			}
+			// the owners are looked up once, for the entries and for the policy
+			owners, err := verifier.ResolveCodeowners(client, codeowners)
+			if err != nil {
+				log.Fatalf("Error looking up owners on provider %s: %s", args[0], err)
+			}
+			findings = append(findings, verifier.CheckResolvedCodeowners(codeowners, owners)...)
+			findings = append(findings, verifier.CheckOwnerSyntax(codeowners, d)...)
+			findings = append(findings, verifier.CheckDuplicates(codeowners)...)
+			if policyFile != "" {
+				policyFindings, err := checkPolicy(owners, codeowners)
+				if err != nil {
+					log.Fatalf("Error checking policy: %s", err)
+				}
+				findings = append(findings, policyFindings...)
+			}
+			findings = verifier.ApplySuppressions(findings, verifier.Suppressions(codeowners, sections))
+			if updateBaseline {
+				if baselineFile == "" {
+					log.Fatal("--update-baseline requires --baseline")
+				}
+				if err := verifier.NewBaseline(findings).Write(baselineFile); err != nil {
+					log.Fatalf("Error updating baseline: %s", err)
+				}
+				log.Infof("Baseline %s updated with %d findings", baselineFile, len(findings))
+				return
+			}
+			if baselineFile != "" {
+				baseline, err := verifier.LoadBaseline(baselineFile)
+				if err != nil {
+					log.Fatalf("Error reading baseline: %s", err)
+				}
+				var suppressed int
+				findings, suppressed = baseline.Filter(findings)
+				log.Infof("Suppressed %d findings listed on baseline %s", suppressed, baselineFile)
+			}
+			reportFindings("validate", filename, findings)
+			verifier.LogFindings(findings)
+			if verifier.HasErrors(findings) {
+				log.Fatal("Invalid CODEOWNERS file")
+			}
 			log.Info("Valid CODEOWNERS file")
-			os.Exit(0)
-		} else {
-			log.Fatal("Invalid CODEOWNERS file")
-		}
-	},
+		},
+	}
+	limits         map[string]int
+	fix            bool
+	policyFile     string
+	baselineFile   string
+	updateBaseline bool
+)
+
+// checkPolicy evaluates the policy file against the CODEOWNERS entries,
+// telling groups from users with the owners looked up on the provider
+func checkPolicy(owners map[string]providers.OwnerInfo, codeowners []*verifier.CodeOwner) ([]verifier.Finding, error) {
+	p, err := policy.Load(policyFile)
+	if err != nil {
+		return nil, err
+	}
+	currentDir, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	files, err := verifier.FilePathWalkDir(currentDir)
+	if err != nil {
+		return nil, err
+	}
+	return p.Check(codeowners, files, func(owner string) (bool, error) {
+		return owners[strings.TrimPrefix(owner, "@")].Group, nil
+	})
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
-        log.SetFormatter(&log.TextFormatter{TimestampFormat: "2006-01-02 15:04:05.000", FullTimestamp: true})
+	withProvidersHelp(validateCmd)
+	validateCmd.Flags().StringToIntVar(&limits, "limit", map[string]int{}, "Override a limit of the dialect, 0 disables it. E.g: owner-count=20,line-length=1000")
+	validateCmd.Flags().BoolVar(&fix, "fix", false, "Remove duplicated patterns and owners from the CODEOWNERS file before validating it")
+	validateCmd.Flags().StringVar(&policyFile, "policy", "", "Path to a YAML file with organizational rules to enforce")
+	validateCmd.Flags().StringVar(&baselineFile, "baseline", "", "Path to a JSON file with known findings to suppress")
+	addCommentFlags(validateCmd)
+	validateCmd.Flags().BoolVar(&updateBaseline, "update-baseline", false, "Write the current findings to the --baseline file instead of failing")
+	log.SetFormatter(&log.TextFormatter{TimestampFormat: "2006-01-02 15:04:05.000", FullTimestamp: true})
 }
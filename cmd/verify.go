@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -17,11 +18,27 @@ var (
 Also, you can specify a list of members to ignore with the flag -i or --ignore. Example:
 codeowners-verifier verify folder1 --ignore @user1 --ignore @group1`,
 		Run: func(cmd *cobra.Command, args []string) {
-			co, err := verifier.ReadCodeownersFile(cmd.Flag(codeowners).Value.String())
+			filename, err := codeownersFile(cmd)
+			if err != nil {
+				log.Fatalf("Couldn't find CODEOWNERS file: %s", err)
+			}
+			co, _, err := readCodeowners(cmd, filename)
 			if err != nil {
 				log.Fatalf("Couldn't read CODEOWNERS file: %s", err)
 			}
 			rule, valid := verifier.VerifyCodeowner(co, args[0], ignore)
+			var findings []verifier.Finding
+			if !valid {
+				findings = append(findings, verifier.Finding{
+					Check:    verifier.CheckMissingOwner,
+					Severity: verifier.SeverityError,
+					Line:     rule.Line,
+					Pattern:  rule.Path,
+					Message:  fmt.Sprintf("Path %s doesn't have valid owners, matched rule from line %d: %s %s", args[0], rule.Line, rule.Path, rule.Owners),
+				})
+			}
+			// each verified path has its own comments
+			reportFindings("verify "+args[0], filename, findings)
 			if valid {
 				log.Infof("Found matching rule on line %d: %s %s", rule.Line, rule.Path, rule.Owners)
 				os.Exit(0)
@@ -35,5 +52,6 @@ codeowners-verifier verify folder1 --ignore @user1 --ignore @group1`,
 
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+	addCommentFlags(verifyCmd)
 	verifyCmd.Flags().StringSliceVarP(&ignore, "ignore", "i", []string{}, "Comma separated list of entries to ignore when validating a path E.g: @user1,@group1,@user2")
 }
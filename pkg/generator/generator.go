@@ -0,0 +1,244 @@
+// Package generator drafts a CODEOWNERS file from the history of the repository
+package generator
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+)
+
+// Contribution represents the lines an author changed on a file in a commit
+type Contribution struct {
+	Path  string
+	Hash  string
+	Name  string
+	Email string
+	Lines int
+}
+
+// FromLog returns the contributions of the commits
+func FromLog(commits []*git.Commit) []Contribution {
+	var contributions []Contribution
+	for _, commit := range commits {
+		for _, file := range commit.Files {
+			contributions = append(contributions, Contribution{
+				Path:  file.Path,
+				Hash:  commit.Hash,
+				Name:  commit.AuthorName,
+				Email: commit.AuthorEmail,
+				Lines: file.Lines(),
+			})
+		}
+	}
+	return contributions
+}
+
+// FromBlame returns the contributions to the current lines of the file
+func FromBlame(file string, lines []git.BlameLine) []Contribution {
+	var contributions []Contribution
+	byHash := make(map[string]int)
+	for _, line := range lines {
+		idx, ok := byHash[line.Hash]
+		if !ok {
+			idx = len(contributions)
+			byHash[line.Hash] = idx
+			contributions = append(contributions, Contribution{Path: file, Hash: line.Hash, Name: line.AuthorName, Email: line.AuthorEmail})
+		}
+		contributions[idx].Lines++
+	}
+	return contributions
+}
+
+// Options controls which contributors are suggested as owners
+type Options struct {
+	// Depth is the deepest directory level suggested, 1 for top level directories
+	Depth int
+	// MinShare is the share of the changed lines of a directory a contributor needs, from 0 to 1
+	MinShare float64
+	// MinCommits is how many commits on a directory a contributor needs
+	MinCommits int
+	// MaxOwners limits the owners suggested for each directory
+	MaxOwners int
+	// Authors maps the git authors to their usernames
+	Authors git.Authors
+}
+
+// Owner represents a contributor suggested as owner of a directory
+type Owner struct {
+	Username string
+	Name     string
+	Commits  int
+	Lines    int
+	Share    float64
+	// NotFound tells the owner doesn't exist on the provider
+	NotFound bool
+}
+
+// Suggestion represents the owners suggested for a CODEOWNERS pattern
+type Suggestion struct {
+	Path    string
+	Owners  []*Owner
+	Commits int
+	Lines   int
+}
+
+// directories returns the CODEOWNERS patterns of the file, * and each
+// of its parent directories up to depth
+func directories(file string, depth int) []string {
+	patterns := []string{"*"}
+	parts := strings.Split(path.Dir(file), "/")
+	for idx := 0; idx < len(parts) && idx < depth && parts[0] != "."; idx++ {
+		patterns = append(patterns, "/"+strings.Join(parts[:idx+1], "/")+"/")
+	}
+	return patterns
+}
+
+// contributor aggregates the contributions of an owner to a pattern
+type contributor struct {
+	owner   *Owner
+	commits map[string]bool
+}
+
+// Suggest returns the owners suggested for the whole repository and for its
+// directories, ordered like they're written on CODEOWNERS. Only contributions
+// to files are used, e.g. the files still on the repository. A directory is left
+// out when it doesn't have enough contributors or they're the owners of its parent
+func Suggest(contributions []Contribution, files []string, opts Options) []*Suggestion {
+	tracked := make(map[string]bool)
+	for _, file := range files {
+		tracked[file] = true
+	}
+	byPattern := make(map[string]map[string]*contributor)
+	commits := make(map[string]map[string]bool)
+	for _, c := range contributions {
+		if !tracked[c.Path] {
+			continue
+		}
+		username := opts.Authors.Username(c.Name, c.Email)
+		for _, pattern := range directories(c.Path, opts.Depth) {
+			if byPattern[pattern] == nil {
+				byPattern[pattern] = make(map[string]*contributor)
+				commits[pattern] = make(map[string]bool)
+			}
+			commits[pattern][c.Hash] = true
+			contrib, ok := byPattern[pattern][username]
+			if !ok {
+				contrib = &contributor{owner: &Owner{Username: username, Name: c.Name}, commits: make(map[string]bool)}
+				byPattern[pattern][username] = contrib
+			}
+			contrib.owner.Lines += c.Lines
+			contrib.commits[c.Hash] = true
+		}
+	}
+
+	var patterns []string
+	for pattern := range byPattern {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	var suggestions []*Suggestion
+	suggested := make(map[string]*Suggestion)
+	for _, pattern := range patterns {
+		suggestion := &Suggestion{Path: pattern, Commits: len(commits[pattern])}
+		var owners []*Owner
+		for _, contrib := range byPattern[pattern] {
+			contrib.owner.Commits = len(contrib.commits)
+			suggestion.Lines += contrib.owner.Lines
+			owners = append(owners, contrib.owner)
+		}
+		sort.Slice(owners, func(i, j int) bool {
+			if owners[i].Lines != owners[j].Lines {
+				return owners[i].Lines > owners[j].Lines
+			}
+			if owners[i].Commits != owners[j].Commits {
+				return owners[i].Commits > owners[j].Commits
+			}
+			return owners[i].Username < owners[j].Username
+		})
+		for _, owner := range owners {
+			if suggestion.Lines > 0 {
+				owner.Share = float64(owner.Lines) / float64(suggestion.Lines)
+			}
+			if owner.Share < opts.MinShare || owner.Commits < opts.MinCommits {
+				continue
+			}
+			if opts.MaxOwners > 0 && len(suggestion.Owners) >= opts.MaxOwners {
+				break
+			}
+			suggestion.Owners = append(suggestion.Owners, owner)
+		}
+		if len(suggestion.Owners) == 0 {
+			continue
+		}
+		if parent := parentSuggestion(suggested, pattern); parent != nil && sameOwners(parent, suggestion) {
+			continue
+		}
+		suggested[pattern] = suggestion
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions
+}
+
+// parentSuggestion returns the suggestion of the closest parent of the pattern
+func parentSuggestion(suggested map[string]*Suggestion, pattern string) *Suggestion {
+	for pattern != "*" {
+		pattern = path.Dir(strings.TrimSuffix(pattern, "/")) + "/"
+		if pattern == "//" {
+			pattern = "*"
+		}
+		if suggestion, ok := suggested[pattern]; ok {
+			return suggestion
+		}
+	}
+	return nil
+}
+
+// sameOwners tells if both suggestions have the same owners
+func sameOwners(a *Suggestion, b *Suggestion) bool {
+	if len(a.Owners) != len(b.Owners) {
+		return false
+	}
+	owners := make(map[string]bool)
+	for _, owner := range a.Owners {
+		owners[owner.Username] = true
+	}
+	for _, owner := range b.Owners {
+		if !owners[owner.Username] {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes the suggestions as a CODEOWNERS draft, with a comment explaining
+// every entry. Owners not found on the provider are only listed on the comment,
+// entries without owners left are commented out
+func Write(w io.Writer, suggestions []*Suggestion) error {
+	var b strings.Builder
+	b.WriteString("# Draft generated by codeowners-verifier from the git history.\n")
+	b.WriteString("# Review the suggestions before committing it.\n")
+	for _, suggestion := range suggestions {
+		var details, owners []string
+		for _, owner := range suggestion.Owners {
+			detail := fmt.Sprintf("%s (%s) %.0f%% of the lines, %d commits", owner.Username, owner.Name, owner.Share*100, owner.Commits)
+			if owner.NotFound {
+				detail += ", not found on the provider"
+			} else {
+				owners = append(owners, owner.Username)
+			}
+			details = append(details, detail)
+		}
+		b.WriteString(fmt.Sprintf("\n# %s: %d commits changing %d lines. %s\n", suggestion.Path, suggestion.Commits, suggestion.Lines, strings.Join(details, "; ")))
+		if len(owners) == 0 {
+			b.WriteString(fmt.Sprintf("# %s\n", suggestion.Path))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s %s\n", suggestion.Path, strings.Join(owners, " ")))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,104 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+)
+
+type TestCase struct {
+	Expected interface{}
+	Sample   interface{}
+	Name     string
+}
+
+// testContributions returns the contributions of alice on api, bob on web and
+// carol on the docs, with a single small commit from dave on the api
+func testContributions() []Contribution {
+	commits := []*git.Commit{
+		{Hash: "1", AuthorName: "Alice", AuthorEmail: "alice@example.com", Files: []git.FileChange{{Path: "api/handler.go", Added: 80}, {Path: "api/v1/users.go", Added: 20}}},
+		{Hash: "2", AuthorName: "Alice", AuthorEmail: "alice@example.com", Files: []git.FileChange{{Path: "api/handler.go", Added: 10, Deleted: 10}}},
+		{Hash: "3", AuthorName: "Bob", AuthorEmail: "bob@example.com", Files: []git.FileChange{{Path: "web/index.js", Added: 50}, {Path: "README.md", Added: 5}}},
+		{Hash: "4", AuthorName: "Bob", AuthorEmail: "bob@example.com", Files: []git.FileChange{{Path: "web/app.js", Added: 50}}},
+		{Hash: "5", AuthorName: "Carol", AuthorEmail: "carol@example.com", Files: []git.FileChange{{Path: "docs/index.md", Added: 30}}},
+		{Hash: "6", AuthorName: "Carol", AuthorEmail: "carol@example.com", Files: []git.FileChange{{Path: "docs/guide.md", Added: 30}, {Path: "removed/old.go", Added: 500}}},
+		{Hash: "7", AuthorName: "Dave", AuthorEmail: "dave@example.com", Files: []git.FileChange{{Path: "api/v1/users.go", Added: 20}}},
+	}
+	return FromLog(commits)
+}
+
+var testFiles = []string{"README.md", "api/handler.go", "api/v1/users.go", "web/index.js", "web/app.js", "docs/index.md", "docs/guide.md"}
+
+func TestSuggest(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "owners of every directory",
+			Sample:   Options{Depth: 2, MinShare: 0.2, MinCommits: 2, MaxOwners: 3},
+			Expected: []string{"* [alice@example.com bob@example.com]", "/api/ [alice@example.com]", "/docs/ [carol@example.com]", "/web/ [bob@example.com]"},
+		},
+		{
+			Name:     "single commit contributors count with min commits 1",
+			Sample:   Options{Depth: 2, MinShare: 0.15, MinCommits: 1, MaxOwners: 3},
+			Expected: []string{"* [alice@example.com bob@example.com carol@example.com]", "/api/ [alice@example.com]", "/api/v1/ [alice@example.com dave@example.com]", "/docs/ [carol@example.com]", "/web/ [bob@example.com]"},
+		},
+		{
+			Name:     "depth 0 only suggests the default owners",
+			Sample:   Options{Depth: 0, MinShare: 0.2, MinCommits: 1, MaxOwners: 1},
+			Expected: []string{"* [alice@example.com]"},
+		},
+		{
+			Name:     "authors are mapped to usernames",
+			Sample:   Options{Depth: 1, MinShare: 0.3, MinCommits: 1, Authors: git.Authors{"ALICE@example.com": "alice", "Bob": "@bob", "Carol": "docs@example.com"}},
+			Expected: []string{"* [@alice @bob]", "/api/ [@alice]", "/docs/ [docs@example.com]", "/web/ [@bob]"},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		var summary []string
+		for _, suggestion := range Suggest(testContributions(), testFiles, test.Sample.(Options)) {
+			var owners []string
+			for _, owner := range suggestion.Owners {
+				owners = append(owners, owner.Username)
+			}
+			summary = append(summary, fmt.Sprintf("%s %v", suggestion.Path, owners))
+		}
+		assert.Equal(t, test.Expected, summary)
+	}
+}
+
+func TestFromBlame(t *testing.T) {
+	lines := []git.BlameLine{
+		{Hash: "1", AuthorName: "Alice", AuthorEmail: "alice@example.com"},
+		{Hash: "2", AuthorName: "Bob", AuthorEmail: "bob@example.com"},
+		{Hash: "1", AuthorName: "Alice", AuthorEmail: "alice@example.com"},
+	}
+	assert.Equal(t, []Contribution{
+		{Path: "main.go", Hash: "1", Name: "Alice", Email: "alice@example.com", Lines: 2},
+		{Path: "main.go", Hash: "2", Name: "Bob", Email: "bob@example.com", Lines: 1},
+	}, FromBlame("main.go", lines))
+}
+
+func TestWrite(t *testing.T) {
+	suggestions := Suggest(testContributions(), testFiles, Options{Depth: 1, MinShare: 0.2, MinCommits: 2, Authors: git.Authors{"alice@example.com": "alice"}})
+	suggestions[1].Owners[0].NotFound = true
+	var b strings.Builder
+	assert.Nil(t, Write(&b, suggestions))
+	assert.Equal(t, `# Draft generated by codeowners-verifier from the git history.
+# Review the suggestions before committing it.
+
+# *: 7 commits changing 305 lines. @alice (Alice) 39% of the lines, 2 commits; bob@example.com (Bob) 34% of the lines, 2 commits
+* @alice bob@example.com
+
+# /api/: 3 commits changing 140 lines. @alice (Alice) 86% of the lines, 2 commits, not found on the provider
+# /api/
+
+# /docs/: 2 commits changing 60 lines. carol@example.com (Carol) 100% of the lines, 2 commits
+/docs/ carol@example.com
+
+# /web/: 2 commits changing 100 lines. bob@example.com (Bob) 100% of the lines, 2 commits
+/web/ bob@example.com
+`, b.String())
+}
@@ -0,0 +1,301 @@
+// Package git reads the history of the local repository running the git command
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Commit represents a non merge commit with the lines it changed on every file
+type Commit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Files       []FileChange
+}
+
+// FileChange represents the lines a commit added and deleted on a file,
+// binary files count as a single changed line
+type FileChange struct {
+	Path    string
+	Added   int
+	Deleted int
+}
+
+// Lines returns how many lines the change touched
+func (f FileChange) Lines() int {
+	return f.Added + f.Deleted
+}
+
+// Run runs git on the repository directory, returning its standard output
+func Run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Error running git %s: %s %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// Separates the fields of the commit headers on the log output
+const fieldSeparator = "\x1f"
+
+// Log returns the non merge commits of the repository, newest first. since and until
+// restrict the time window and accept any git date, e.g. "1 year ago", empty for no limit.
+// Changes made before a file was renamed are reported on its current path
+func Log(dir string, since string, until string, paths ...string) ([]*Commit, error) {
+	args := []string{"log", "--no-merges", "--numstat", "--format=" + fieldSeparator + strings.Join([]string{"%H", "%an", "%ae", "%aI"}, fieldSeparator)}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if until != "" {
+		args = append(args, "--until="+until)
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	output, err := Run(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseLog(output)
+}
+
+// parseLog reads the commits of the log output
+func parseLog(output string) ([]*Commit, error) {
+	var commits []*Commit
+	var commit *Commit
+	// renamed maps the old paths to the current ones, the log is read newest first
+	renamed := make(map[string]string)
+	current := func(path string) string {
+		if newPath, ok := renamed[path]; ok {
+			return newPath
+		}
+		return path
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, fieldSeparator) {
+			fields := strings.Split(strings.TrimPrefix(line, fieldSeparator), fieldSeparator)
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("Invalid git log header: %s", line)
+			}
+			date, err := time.Parse(time.RFC3339, fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid git log date: %s", err)
+			}
+			commit = &Commit{Hash: fields[0], AuthorName: fields[1], AuthorEmail: fields[2], Date: date}
+			commits = append(commits, commit)
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if commit == nil || len(fields) != 3 {
+			continue
+		}
+		change := FileChange{Path: current(fields[2]), Added: 1}
+		if oldPath, newPath, ok := parseRename(fields[2]); ok {
+			change.Path = current(newPath)
+			renamed[oldPath] = change.Path
+		}
+		if fields[0] != "-" {
+			change.Added, _ = strconv.Atoi(fields[0])
+			change.Deleted, _ = strconv.Atoi(fields[1])
+		}
+		commit.Files = append(commit.Files, change)
+	}
+	return commits, nil
+}
+
+// parseRename reads the paths of a renamed file on the numstat output,
+// written as old => new or with the common parts outside braces, e.g. src/{old => new}/main.go
+func parseRename(path string) (string, string, bool) {
+	start, end := strings.Index(path, "{"), strings.LastIndex(path, "}")
+	if start == -1 || end < start {
+		oldPath, newPath, ok := strings.Cut(path, " => ")
+		return oldPath, newPath, ok
+	}
+	oldPart, newPart, ok := strings.Cut(path[start+1:end], " => ")
+	if !ok {
+		oldPath, newPath, ok := strings.Cut(path, " => ")
+		return oldPath, newPath, ok
+	}
+	join := func(part string) string {
+		// an empty side leaves the separators of both common parts, e.g. src/{ => lib}/main.go
+		joined := path[:start] + part + path[end+1:]
+		return strings.TrimPrefix(strings.Replace(joined, "//", "/", 1), "/")
+	}
+	return join(oldPart), join(newPart), true
+}
+
+// BlameLine represents the author of a line of the current version of a file
+type BlameLine struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+}
+
+// Blame returns the author of every line of the file, lines last changed before
+// since and lines not committed yet are left out. since accepts any git date, empty for no limit
+func Blame(dir string, file string, since string) ([]BlameLine, error) {
+	return BlameAt(dir, "", file, since)
+}
+
+// BlameAt does the same as Blame on the version of the file on the revision,
+// an empty revision blames the working tree
+func BlameAt(dir string, rev string, file string, since string) ([]BlameLine, error) {
+	args := []string{"blame", "--line-porcelain", "--root"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if rev != "" {
+		args = append(args, rev)
+	}
+	output, err := Run(dir, append(args, "--", file)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(output), nil
+}
+
+// parseBlame reads the lines of the blame porcelain output
+func parseBlame(output string) []BlameLine {
+	var lines []BlameLine
+	var current BlameLine
+	boundary := false
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			// the content of the line ends every entry
+			if !boundary && strings.Trim(current.Hash, "0") != "" {
+				lines = append(lines, current)
+			}
+			current, boundary = BlameLine{}, false
+		case current.Hash == "" && len(key) == 40:
+			current.Hash = key
+		case key == "author":
+			current.AuthorName = value
+		case key == "author-mail":
+			current.AuthorEmail = strings.Trim(value, "<>")
+		case key == "author-time":
+			seconds, _ := strconv.ParseInt(value, 10, 64)
+			current.Date = time.Unix(seconds, 0)
+		case key == "boundary":
+			boundary = true
+		}
+	}
+	return lines
+}
+
+// ListFiles returns the files tracked on the repository
+func ListFiles(dir string) ([]string, error) {
+	output, err := Run(dir, "ls-files")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+// ListFilesAt returns the files of the repository on the revision
+func ListFilesAt(dir string, rev string) ([]string, error) {
+	output, err := Run(dir, "ls-tree", "-r", "--name-only", rev)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(output), nil
+}
+
+// ListSubmodules returns the paths of the submodules of the repository on the revision,
+// which are listed as files but have no history of their own
+func ListSubmodules(dir string, rev string) ([]string, error) {
+	output, err := Run(dir, "ls-tree", "-r", rev)
+	if err != nil {
+		return nil, err
+	}
+	var submodules []string
+	for _, line := range splitLines(output) {
+		info, path, ok := strings.Cut(line, "\t")
+		if ok && strings.HasPrefix(info, "160000 ") {
+			submodules = append(submodules, path)
+		}
+	}
+	return submodules, nil
+}
+
+// RevisionBefore returns the last commit of HEAD before the date, any date git accepts
+func RevisionBefore(dir string, until string) (string, error) {
+	output, err := Run(dir, "rev-list", "-1", "--before="+until, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		return "", fmt.Errorf("No commits before %s", until)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Show returns the contents of the file on the revision, the file path is relative to the repository root
+func Show(dir string, rev string, file string) (string, error) {
+	return Run(dir, "show", rev+":"+file)
+}
+
+// splitLines returns the non empty lines of the output
+func splitLines(output string) []string {
+	var files []string
+	for _, file := range strings.Split(output, "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Authors maps the email or the name of the git authors to their usernames
+type Authors map[string]string
+
+// LoadAuthors reads the mapping of author emails or names to usernames from a YAML file
+func LoadAuthors(filename string) (Authors, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open authors file: %s", err)
+	}
+	authors := make(Authors)
+	if err := yaml.Unmarshal(content, &authors); err != nil {
+		return nil, fmt.Errorf("Invalid authors file: %s", err)
+	}
+	return authors, nil
+}
+
+// Username returns the CODEOWNERS owner of the author, the mapped username
+// looked up by email and then by name, or else the email of the author
+func (a Authors) Username(name string, email string) string {
+	username, _ := a.Lookup(name, email)
+	return username
+}
+
+// Lookup returns the same as Username, telling if the author was mapped to a username
+func (a Authors) Lookup(name string, email string) (string, bool) {
+	for _, author := range []string{email, name} {
+		for key, value := range a {
+			if !strings.EqualFold(key, author) {
+				continue
+			}
+			if !strings.Contains(value, "@") {
+				value = "@" + value
+			}
+			return value, true
+		}
+	}
+	return email, false
+}
@@ -0,0 +1,183 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+// commitFile writes the file and commits it with the author
+func commitFile(t *testing.T, dir string, file string, content string, author string, date string) {
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+	_, err := Run(dir, "add", file)
+	assert.Nil(t, err)
+	t.Setenv("GIT_AUTHOR_DATE", date)
+	t.Setenv("GIT_COMMITTER_DATE", date)
+	_, err = Run(dir, "-c", "user.name=committer", "-c", "user.email=committer@example.com", "commit", "-q", "--author", author, "-m", "change "+file)
+	assert.Nil(t, err)
+}
+
+func TestLog(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	_, err := Run(dir, "init", "-q")
+	assert.Nil(t, err)
+	commitFile(t, dir, "api/handler.go", "a\nb\n", "Alice <alice@example.com>", "2022-01-01T10:00:00Z")
+	commitFile(t, dir, "README.md", "readme\n", "Bob <bob@example.com>", "2023-06-01T10:00:00Z")
+	commitFile(t, dir, "api/handler.go", "a\nc\n", "Bob <bob@example.com>", "2023-07-01T10:00:00Z")
+
+	commits, err := Log(dir, "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(commits))
+	assert.Equal(t, "Bob", commits[0].AuthorName)
+	assert.Equal(t, "bob@example.com", commits[0].AuthorEmail)
+	assert.Equal(t, []FileChange{{Path: "api/handler.go", Added: 1, Deleted: 1}}, commits[0].Files)
+	assert.Equal(t, 2023, commits[0].Date.Year())
+
+	commits, err = Log(dir, "2023-01-01", "2023-06-30")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commits))
+	assert.Equal(t, "README.md", commits[0].Files[0].Path)
+
+	commits, err = Log(dir, "", "", "api")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(commits))
+
+	lines, err := Blame(dir, "api/handler.go", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "alice@example.com", lines[0].AuthorEmail)
+	assert.Equal(t, "bob@example.com", lines[1].AuthorEmail)
+
+	lines, err = Blame(dir, "api/handler.go", "2023-01-01")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(lines))
+	assert.Equal(t, "Bob", lines[0].AuthorName)
+
+	rev, err := RevisionBefore(dir, "2023-06-30")
+	assert.Nil(t, err)
+	lines, err = BlameAt(dir, rev, "api/handler.go", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lines))
+	assert.Equal(t, "alice@example.com", lines[1].AuthorEmail)
+	_, err = RevisionBefore(dir, "2021-01-01")
+	assert.Equal(t, fmt.Errorf("No commits before 2021-01-01"), err)
+
+	files, err := ListFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"README.md", "api/handler.go"}, files)
+
+	files, err = ListFilesAt(dir, "HEAD~2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api/handler.go"}, files)
+	_, err = ListFilesAt(dir, "HEAD~3")
+	assert.NotNil(t, err)
+
+	content, err := Show(dir, "HEAD~1", "api/handler.go")
+	assert.Nil(t, err)
+	assert.Equal(t, "a\nb\n", content)
+	_, err = Show(dir, "HEAD", "missing.go")
+	assert.NotNil(t, err)
+
+	_, err = Log(filet.TmpDir(t, ""), "", "")
+	assert.NotNil(t, err)
+}
+
+func TestLogRenames(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	_, err := Run(dir, "init", "-q")
+	assert.Nil(t, err)
+	commitFile(t, dir, "src/handler.go", "a\nb\nc\nd\n", "Alice <alice@example.com>", "2022-01-01T10:00:00Z")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "src", "api"), 0755))
+	_, err = Run(dir, "mv", "src/handler.go", "src/api/handler.go")
+	assert.Nil(t, err)
+	commitFile(t, dir, "src/api/handler.go", "a\nb\nc\ne\n", "Bob <bob@example.com>", "2023-01-01T10:00:00Z")
+
+	commits, err := Log(dir, "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(commits))
+	assert.Equal(t, []FileChange{{Path: "src/api/handler.go", Added: 1, Deleted: 1}}, commits[0].Files)
+	assert.Equal(t, []FileChange{{Path: "src/api/handler.go", Added: 4}}, commits[1].Files)
+}
+
+func TestParseRename(t *testing.T) {
+	renames := map[string][]string{
+		"main.go => cmd/main.go":   {"main.go", "cmd/main.go"},
+		"src/{old => new}/main.go": {"src/old/main.go", "src/new/main.go"},
+		"src/{ => api}/main.go":    {"src/main.go", "src/api/main.go"},
+		"{api => }/main.go":        {"api/main.go", "main.go"},
+		"src/{a}.go => src/b.go":   {"src/{a}.go", "src/b.go"},
+	}
+	for path, expected := range renames {
+		oldPath, newPath, ok := parseRename(path)
+		assert.Equal(t, true, ok, path)
+		assert.Equal(t, expected, []string{oldPath, newPath}, path)
+	}
+	_, _, ok := parseRename("src/main.go")
+	assert.Equal(t, false, ok)
+}
+
+func TestListSubmodules(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	_, err := Run(dir, "init", "-q")
+	assert.Nil(t, err)
+	commitFile(t, dir, "README.md", "readme\n", "Alice <alice@example.com>", "2023-01-01T10:00:00Z")
+	head, err := Run(dir, "rev-parse", "HEAD")
+	assert.Nil(t, err)
+	// a gitlink, like the ones added by git submodule add, without cloning the submodule
+	_, err = Run(dir, "update-index", "--add", "--cacheinfo", "160000,"+strings.TrimSpace(head)+",vendor/lib")
+	assert.Nil(t, err)
+	_, err = Run(dir, "-c", "user.name=committer", "-c", "user.email=committer@example.com", "commit", "-q", "-m", "add submodule")
+	assert.Nil(t, err)
+
+	submodules, err := ListSubmodules(dir, "HEAD")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"vendor/lib"}, submodules)
+	files, err := ListFiles(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"README.md", "vendor/lib"}, files)
+}
+
+func TestParseLog(t *testing.T) {
+	output := "\x1fabc\x1fAlice\x1falice@example.com\x1f2023-01-01T10:00:00Z\n\n3\t1\tmain.go\n-\t-\tlogo.png\n"
+	commits, err := parseLog(output)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(commits))
+	assert.Equal(t, []FileChange{{Path: "main.go", Added: 3, Deleted: 1}, {Path: "logo.png", Added: 1}}, commits[0].Files)
+	assert.Equal(t, 4, commits[0].Files[0].Lines())
+
+	_, err = parseLog("\x1fabc\x1fAlice\n")
+	assert.NotNil(t, err)
+}
+
+func TestLoadAuthors(t *testing.T) {
+	defer filet.CleanUp(t)
+	authors, err := LoadAuthors(filet.TmpFile(t, "", "alice@example.com: alice\nBob Smith: \"@bob\"\n").Name())
+	assert.Nil(t, err)
+	assert.Equal(t, Authors{"alice@example.com": "alice", "Bob Smith": "@bob"}, authors)
+	_, err = LoadAuthors(filet.TmpFile(t, "", "- alice\n").Name())
+	assert.NotNil(t, err)
+	_, err = LoadAuthors("/nonexistent/authors.yaml")
+	assert.NotNil(t, err)
+}
+
+func TestAuthorsUsername(t *testing.T) {
+	authors := Authors{"ALICE@example.com": "alice", "Bob": "@bob", "Carol": "docs@example.com"}
+	assert.Equal(t, "@alice", authors.Username("Alice Smith", "alice@example.com"))
+	assert.Equal(t, "@bob", authors.Username("bob", "bob@example.com"))
+	assert.Equal(t, "docs@example.com", authors.Username("Carol", "carol@example.com"))
+	assert.Equal(t, "dave@example.com", authors.Username("Dave", "dave@example.com"))
+	assert.Equal(t, "dave@example.com", Authors(nil).Username("Dave", "dave@example.com"))
+	_, mapped := authors.Lookup("Dave", "dave@example.com")
+	assert.Equal(t, false, mapped)
+	_, mapped = authors.Lookup("Carol", "carol@example.com")
+	assert.Equal(t, true, mapped)
+}
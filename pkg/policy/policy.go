@@ -0,0 +1,314 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+	"gopkg.in/yaml.v3"
+)
+
+// Types of policy rules
+const (
+	// RequireGroupOwner requires every CODEOWNERS rule to have at least one group owner
+	RequireGroupOwner = "require-group-owner"
+	// NoSoleIndividual forbids files from being owned by a single user
+	NoSoleIndividual = "no-sole-individual"
+	// MaxPathsPerOwner limits how many repository files an owner can own
+	MaxPathsPerOwner = "max-paths-per-owner"
+	// ProtectedPath requires files to be owned by the given owners
+	ProtectedPath = "protected-path"
+)
+
+// Rule represents a single organizational rule applied to a CODEOWNERS file
+type Rule struct {
+	Name     string            `yaml:"name"`
+	Type     string            `yaml:"type"`
+	Severity verifier.Severity `yaml:"severity"`
+	// Paths restricts the rule to files matching any of these CODEOWNERS patterns.
+	// When empty, the rule applies to every file
+	Paths  []string `yaml:"paths"`
+	Owners []string `yaml:"owners"`
+	Max    int      `yaml:"max"`
+}
+
+// Policy represents a set of organizational rules
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// GroupChecker returns true when the owner is a group
+type GroupChecker func(owner string) (bool, error)
+
+// Load reads a Policy from a YAML file
+func Load(filename string) (*Policy, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open policy file: %s", err)
+	}
+	return Parse(content)
+}
+
+// Parse reads a Policy from YAML contents, checking every rule is valid
+func Parse(content []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := yaml.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("Invalid policy: %s", err)
+	}
+	for idx := range policy.Rules {
+		r := &policy.Rules[idx]
+		if r.Name == "" {
+			r.Name = r.Type
+		}
+		if r.Severity == "" {
+			r.Severity = verifier.SeverityError
+		}
+		if r.Severity != verifier.SeverityError && r.Severity != verifier.SeverityWarning {
+			return nil, fmt.Errorf("Invalid severity %s on policy rule %s", r.Severity, r.Name)
+		}
+		for _, path := range r.Paths {
+			if _, err := verifier.NewCodeOwner(path, nil); err != nil {
+				return nil, fmt.Errorf("Invalid path %s on policy rule %s: %s", path, r.Name, err)
+			}
+		}
+		switch r.Type {
+		case RequireGroupOwner, NoSoleIndividual:
+		case MaxPathsPerOwner:
+			if r.Max <= 0 {
+				return nil, fmt.Errorf("Policy rule %s needs a positive max", r.Name)
+			}
+		case ProtectedPath:
+			if len(r.Paths) == 0 || len(r.Owners) == 0 {
+				return nil, fmt.Errorf("Policy rule %s needs paths and owners", r.Name)
+			}
+		default:
+			return nil, fmt.Errorf("Invalid type %s on policy rule %s", r.Type, r.Name)
+		}
+	}
+	return policy, nil
+}
+
+// Check evaluates every rule of the policy against the CODEOWNERS entries and the
+// repository files, returning a Finding for each violation
+func (p *Policy) Check(codeowners []*verifier.CodeOwner, files []string, isGroup GroupChecker) ([]verifier.Finding, error) {
+	var findings []verifier.Finding
+	cache := make(map[string]bool)
+	cachedIsGroup := func(owner string) (bool, error) {
+		if group, ok := cache[owner]; ok {
+			return group, nil
+		}
+		group, err := isGroup(owner)
+		if err != nil {
+			return false, err
+		}
+		cache[owner] = group
+		return group, nil
+	}
+	for _, r := range p.Rules {
+		var ruleFindings []verifier.Finding
+		var err error
+		switch r.Type {
+		case RequireGroupOwner:
+			ruleFindings, err = r.checkRequireGroupOwner(codeowners, files, cachedIsGroup)
+		case NoSoleIndividual:
+			ruleFindings, err = r.checkNoSoleIndividual(codeowners, files, cachedIsGroup)
+		case MaxPathsPerOwner:
+			ruleFindings = r.checkMaxPathsPerOwner(codeowners, files)
+		case ProtectedPath:
+			ruleFindings = r.checkProtectedPath(codeowners, files)
+		}
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, ruleFindings...)
+	}
+	return findings, nil
+}
+
+// finding returns a Finding for a violation of the rule
+func (r Rule) finding(line int, pattern string, owner string, format string, a ...interface{}) verifier.Finding {
+	return verifier.Finding{
+		Check:    "policy:" + r.Name,
+		Severity: r.Severity,
+		Line:     line,
+		Pattern:  pattern,
+		Owner:    owner,
+		Message:  fmt.Sprintf("Policy %s: %s", r.Name, fmt.Sprintf(format, a...)),
+	}
+}
+
+// inScope returns true if the file matches any of the rule paths
+func (r Rule) inScope(file string) bool {
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, path := range r.Paths {
+		// the paths are checked on Parse
+		if c, err := verifier.NewCodeOwner(path, nil); err == nil && c.MatchesPath(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveRules returns the CODEOWNERS entries that own at least one file in scope,
+// the last matching entry of every section like GitLab does
+func (r Rule) effectiveRules(codeowners []*verifier.CodeOwner, files []string) []*verifier.CodeOwner {
+	var rules []*verifier.CodeOwner
+	seen := make(map[*verifier.CodeOwner]bool)
+	for _, file := range files {
+		if !r.inScope(file) {
+			continue
+		}
+		for _, c := range verifier.EffectiveRules(codeowners, file) {
+			if !seen[c] {
+				seen[c] = true
+				rules = append(rules, c)
+			}
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Line < rules[j].Line })
+	return rules
+}
+
+// lastRule returns the effective entry of the file written last, used to point
+// findings about the owners of the file to a line
+func lastRule(codeowners []*verifier.CodeOwner, file string) *verifier.CodeOwner {
+	last := &verifier.CodeOwner{}
+	for _, c := range verifier.EffectiveRules(codeowners, file) {
+		if c.Line >= last.Line {
+			last = c
+		}
+	}
+	return last
+}
+
+func (r Rule) checkRequireGroupOwner(codeowners []*verifier.CodeOwner, files []string, isGroup GroupChecker) ([]verifier.Finding, error) {
+	var findings []verifier.Finding
+	rules := codeowners
+	if len(r.Paths) > 0 {
+		rules = r.effectiveRules(codeowners, files)
+	}
+	for _, c := range rules {
+		hasGroup := false
+		for _, owner := range c.Owners {
+			group, err := isGroup(owner)
+			if err != nil {
+				return nil, err
+			}
+			if group {
+				hasGroup = true
+				break
+			}
+		}
+		if !hasGroup {
+			findings = append(findings, r.finding(c.Line, c.Path, "", "line %d: %s has no group owner", c.Line, c.Path))
+		}
+	}
+	return findings, nil
+}
+
+func (r Rule) checkNoSoleIndividual(codeowners []*verifier.CodeOwner, files []string, isGroup GroupChecker) ([]verifier.Finding, error) {
+	var rules []*verifier.CodeOwner
+	seen := make(map[*verifier.CodeOwner]bool)
+	for _, file := range files {
+		if !r.inScope(file) {
+			continue
+		}
+		// the owners of every section own the file together
+		owners := verifier.EffectiveOwners(codeowners, file)
+		if len(owners) != 1 {
+			continue
+		}
+		group, err := isGroup(owners[0])
+		if err != nil {
+			return nil, err
+		}
+		if group {
+			continue
+		}
+		for _, c := range verifier.EffectiveRules(codeowners, file) {
+			if !seen[c] {
+				seen[c] = true
+				rules = append(rules, c)
+			}
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Line < rules[j].Line })
+	var findings []verifier.Finding
+	for _, c := range rules {
+		findings = append(findings, r.finding(c.Line, c.Path, c.Owners[0], "line %d: %s is owned only by the individual %s", c.Line, c.Path, c.Owners[0]))
+	}
+	return findings, nil
+}
+
+func (r Rule) checkMaxPathsPerOwner(codeowners []*verifier.CodeOwner, files []string) []verifier.Finding {
+	var findings []verifier.Finding
+	owned := make(map[string]int)
+	var owners []string
+	for _, file := range files {
+		if !r.inScope(file) {
+			continue
+		}
+		for _, owner := range verifier.EffectiveOwners(codeowners, file) {
+			if _, ok := owned[owner]; !ok {
+				owners = append(owners, owner)
+			}
+			owned[owner]++
+		}
+	}
+	for _, owner := range owners {
+		if owned[owner] > r.Max {
+			findings = append(findings, r.finding(0, "", owner, "%s owns %d files, maximum allowed is %d", owner, owned[owner], r.Max))
+		}
+	}
+	return findings
+}
+
+func (r Rule) checkProtectedPath(codeowners []*verifier.CodeOwner, files []string) []verifier.Finding {
+	var findings []verifier.Finding
+	check := func(file string) {
+		if missing := missingOwners(r.Owners, verifier.EffectiveOwners(codeowners, file)); len(missing) > 0 {
+			c := lastRule(codeowners, file)
+			findings = append(findings, r.finding(c.Line, c.Path, strings.Join(missing, ","), "%s must be owned by %s", file, strings.Join(missing, " ")))
+		}
+	}
+	for _, path := range r.Paths {
+		protected, err := verifier.NewCodeOwner(path, nil)
+		if err != nil {
+			continue
+		}
+		matched := false
+		for _, file := range files {
+			if protected.MatchesPath(file) {
+				matched = true
+				check(file)
+			}
+		}
+		if !matched {
+			// the path doesn't exist yet, it still must have a rule for when it's created
+			check(path)
+		}
+	}
+	return findings
+}
+
+// missingOwners returns the required owners that aren't on owners
+func missingOwners(required []string, owners []string) []string {
+	var missing []string
+	for _, r := range required {
+		found := false
+		for _, o := range owners {
+			if strings.EqualFold(r, o) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+type TestCase struct {
+	Expected interface{}
+	Sample   interface{}
+	Name     string
+}
+
+func isGroup(owner string) (bool, error) {
+	return strings.HasPrefix(owner, "@group"), nil
+}
+
+func codeownersFromLines(lines ...string) []*verifier.CodeOwner {
+	var codeowners []*verifier.CodeOwner
+	for idx, line := range lines {
+		fields := strings.Fields(line)
+		c, _ := verifier.NewCodeOwner(fields[0], fields[1:])
+		c.Line = idx + 1
+		codeowners = append(codeowners, c)
+	}
+	return codeowners
+}
+
+func TestParse(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "valid policy",
+			Sample:   "rules:\n  - type: require-group-owner\n  - type: max-paths-per-owner\n    max: 2\n    severity: warning",
+			Expected: false,
+		},
+		{
+			Name:     "invalid type",
+			Sample:   "rules:\n  - type: non-existent",
+			Expected: true,
+		},
+		{
+			Name:     "invalid severity",
+			Sample:   "rules:\n  - type: require-group-owner\n    severity: fatal",
+			Expected: true,
+		},
+		{
+			Name:     "max-paths-per-owner without max",
+			Sample:   "rules:\n  - type: max-paths-per-owner",
+			Expected: true,
+		},
+		{
+			Name:     "protected-path without owners",
+			Sample:   "rules:\n  - type: protected-path\n    paths: [/.gitlab-ci.yml]",
+			Expected: true,
+		},
+		{
+			Name:     "invalid path",
+			Sample:   "rules:\n  - type: require-group-owner\n    paths: ['!']",
+			Expected: true,
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		p, err := Parse([]byte(test.Sample.(string)))
+		if test.Expected.(bool) {
+			assert.Error(t, err)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, verifier.SeverityError, p.Rules[0].Severity)
+			assert.Equal(t, RequireGroupOwner, p.Rules[0].Name)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	codeowners := codeownersFromLines(
+		"* @group-default",
+		"/services/ @group-services",
+		"/services/billing/ @user1",
+		"/docs/ @user2 @user3",
+		"/.gitlab-ci.yml @user1",
+	)
+	files := []string{
+		"/README.md",
+		"/.gitlab-ci.yml",
+		"/services/api/main.go",
+		"/services/billing/main.go",
+		"/docs/index.md",
+		"/docs/guide.md",
+	}
+	tests := []TestCase{
+		{
+			Name:     "require group owner",
+			Sample:   "rules:\n  - type: require-group-owner",
+			Expected: []string{"/services/billing/", "/docs/", "/.gitlab-ci.yml"},
+		},
+		{
+			Name:     "require group owner under a path",
+			Sample:   "rules:\n  - type: require-group-owner\n    paths: [/docs/]",
+			Expected: []string{"/docs/"},
+		},
+		{
+			Name:     "no sole individual under services",
+			Sample:   "rules:\n  - type: no-sole-individual\n    paths: [/services/]",
+			Expected: []string{"/services/billing/"},
+		},
+		{
+			Name:     "protected path",
+			Sample:   "rules:\n  - type: protected-path\n    paths: [/.gitlab-ci.yml, /Makefile]\n    owners: ['@platform']",
+			Expected: []string{"/.gitlab-ci.yml", "*"},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		p, err := Parse([]byte(test.Sample.(string)))
+		assert.Nil(t, err)
+		findings, err := p.Check(codeowners, files, isGroup)
+		assert.Nil(t, err)
+		var patterns []string
+		for _, f := range findings {
+			patterns = append(patterns, f.Pattern)
+			assert.True(t, strings.HasPrefix(f.Check, "policy:"))
+		}
+		assert.Equal(t, test.Expected.([]string), patterns)
+	}
+}
+
+func TestCheckMaxPathsPerOwner(t *testing.T) {
+	codeowners := codeownersFromLines(
+		"* @group-default",
+		"/services/ @group-services",
+		"/services/billing/ @user1",
+		"/docs/ @user2 @user3",
+		"/.gitlab-ci.yml @user1",
+	)
+	files := []string{
+		"/README.md",
+		"/.gitlab-ci.yml",
+		"/services/api/main.go",
+		"/services/billing/main.go",
+		"/docs/index.md",
+		"/docs/guide.md",
+	}
+	tests := []TestCase{
+		{
+			Name:   "counts the files owned",
+			Sample: "rules:\n  - type: max-paths-per-owner\n    max: 1",
+			Expected: map[string]string{
+				"@user1": "@user1 owns 2 files, maximum allowed is 1",
+				"@user2": "@user2 owns 2 files, maximum allowed is 1",
+				"@user3": "@user3 owns 2 files, maximum allowed is 1",
+			},
+		},
+		{
+			Name:     "under a path",
+			Sample:   "rules:\n  - type: max-paths-per-owner\n    max: 1\n    paths: [/services/]",
+			Expected: map[string]string{},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		p, err := Parse([]byte(test.Sample.(string)))
+		assert.Nil(t, err)
+		findings, err := p.Check(codeowners, files, isGroup)
+		assert.Nil(t, err)
+		messages := make(map[string]string)
+		for _, f := range findings {
+			messages[f.Owner] = strings.TrimPrefix(f.Message, "Policy max-paths-per-owner: ")
+		}
+		assert.Equal(t, test.Expected.(map[string]string), messages)
+	}
+}
+
+func TestCheckSections(t *testing.T) {
+	codeowners, _, err := verifier.ParseCodeowners(strings.NewReader(`* @group-default
+/services/ @user1
+/.gitlab-ci.yml @user1
+
+[Security]
+/.gitlab-ci.yml @platform
+/services/ @group-security
+`))
+	assert.Nil(t, err)
+	files := []string{"/README.md", "/.gitlab-ci.yml", "/services/main.go"}
+	tests := []TestCase{
+		{
+			Name:     "protected path owned on another section",
+			Sample:   "rules:\n  - type: protected-path\n    paths: [/.gitlab-ci.yml]\n    owners: ['@platform']",
+			Expected: []string(nil),
+		},
+		{
+			Name:     "no sole individual with a group on another section",
+			Sample:   "rules:\n  - type: no-sole-individual",
+			Expected: []string(nil),
+		},
+		{
+			Name:     "max paths per owner counts every section",
+			Sample:   "rules:\n  - type: max-paths-per-owner\n    max: 1",
+			Expected: []string{"@user1"},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		p, err := Parse([]byte(test.Sample.(string)))
+		assert.Nil(t, err)
+		findings, err := p.Check(codeowners, files, isGroup)
+		assert.Nil(t, err)
+		var owners []string
+		for _, f := range findings {
+			owners = append(owners, f.Owner)
+		}
+		assert.Equal(t, test.Expected.([]string), owners)
+	}
+}
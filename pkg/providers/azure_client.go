@@ -0,0 +1,151 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Azure represents an Azure DevOps Client configuration.
+// BaseURL points to the organization on the identity service,
+// e.g. https://vssps.dev.azure.com/<organization>
+type Azure struct {
+	Token   string
+	BaseURL string
+	client  *http.Client
+	// groups caches the principal names of the organization groups, lower cased
+	groups map[string]bool
+}
+
+// azureIdentity represents an identity returned by the identities API
+type azureIdentity struct {
+	ProviderDisplayName string `json:"providerDisplayName"`
+	IsContainer         bool   `json:"isContainer"`
+	Properties          map[string]struct {
+		Value string `json:"$value"`
+	} `json:"properties"`
+}
+
+// azureGraphGroup represents a group returned by the graph API
+type azureGraphGroup struct {
+	PrincipalName string `json:"principalName"`
+}
+
+// Init initializes the Azure DevOps Client
+func (a *Azure) Init() error {
+	if a.Token == "" {
+		return fmt.Errorf("Token can't be empty")
+	}
+	// the APIs are scoped to the organization, there's no default for it
+	a.BaseURL = strings.TrimSuffix(a.BaseURL, "/")
+	u, err := url.Parse(a.BaseURL)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("Base URL must point to the organization, e.g. https://vssps.dev.azure.com/<organization>")
+	}
+	if a.client == nil {
+		a.client = newHTTPClient(nil)
+	}
+	return nil
+}
+
+// get calls the Azure DevOps API decoding the response into v,
+// returning the continuation token for paginated responses
+func (a *Azure) get(path string, query url.Values, v interface{}) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, a.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	// Personal access tokens are sent as the password of a basic auth with an empty user
+	req.SetBasicAuth("", a.Token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.Header.Get("X-MS-ContinuationToken"), json.NewDecoder(resp.Body).Decode(v)
+}
+
+// UserExists searches an user by e-mail, or account name, through the identities API
+func (a *Azure) UserExists(name string) (bool, error) {
+	name = unquoteOwner(name)
+	if strings.HasPrefix(name, "[") {
+		return false, nil
+	}
+	query := url.Values{}
+	query.Set("searchFilter", "General")
+	if strings.Contains(name, "@") {
+		query.Set("searchFilter", "MailAddress")
+	}
+	query.Set("filterValue", name)
+	query.Set("queryMembership", "None")
+	query.Set("api-version", "7.0")
+	result := struct {
+		Value []azureIdentity `json:"value"`
+	}{}
+	if _, err := a.get("/_apis/identities", query, &result); err != nil {
+		return false, fmt.Errorf("Error searching for user %s: %s", name, err)
+	}
+	for _, identity := range result.Value {
+		if identity.IsContainer {
+			continue
+		}
+		for _, property := range []string{"Account", "Mail"} {
+			if strings.EqualFold(identity.Properties[property].Value, name) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// listGroups returns the principal names of the organization groups, the graph API can't
+// filter groups by name so every page is read once and cached
+func (a *Azure) listGroups() (map[string]bool, error) {
+	if a.groups != nil {
+		return a.groups, nil
+	}
+	groups := make(map[string]bool)
+	continuationToken := ""
+	for {
+		query := url.Values{}
+		query.Set("api-version", "7.1-preview.1")
+		if continuationToken != "" {
+			query.Set("continuationToken", continuationToken)
+		}
+		result := struct {
+			Value []azureGraphGroup `json:"value"`
+		}{}
+		next, err := a.get("/_apis/graph/groups", query, &result)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range result.Value {
+			groups[strings.ToLower(group.PrincipalName)] = true
+		}
+		if next == "" {
+			break
+		}
+		continuationToken = next
+	}
+	a.groups = groups
+	return groups, nil
+}
+
+// GroupExists searches a group written as [Org]\Team or [Project]\Team through the graph API
+func (a *Azure) GroupExists(name string) (bool, error) {
+	name = unquoteOwner(name)
+	if !strings.HasPrefix(name, "[") {
+		return false, nil
+	}
+	groups, err := a.listGroups()
+	if err != nil {
+		return false, fmt.Errorf("Error searching for group %s: %s", name, err)
+	}
+	return groups[strings.ToLower(name)], nil
+}
@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newAzureServer returns an Azure DevOps stand-in with the user john@contoso.com
+// and the [Contoso]\Web Team group on the second page of groups, counting the
+// requests to the groups API on groupRequests
+func newAzureServer(t *testing.T, groupRequests *int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/contoso/_apis/identities", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("searchFilter") == "MailAddress" && query.Get("filterValue") == "john@contoso.com" {
+			fmt.Fprint(w, `{"count":1,"value":[{"providerDisplayName":"John","isContainer":false,"properties":{"Mail":{"$value":"John@Contoso.com"}}}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"count":0,"value":[]}`)
+	})
+	mux.HandleFunc("/contoso/_apis/graph/groups", func(w http.ResponseWriter, r *http.Request) {
+		*groupRequests++
+		if r.URL.Query().Get("continuationToken") == "" {
+			w.Header().Set("X-MS-ContinuationToken", "page2")
+			fmt.Fprint(w, `{"count":1,"value":[{"principalName":"[Contoso]\\Project Collection Administrators"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"count":1,"value":[{"principalName":"[Contoso]\\Web Team"}]}`)
+	})
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, _ := r.BasicAuth(); password != "example_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestAzureInit(t *testing.T) {
+	client := &Azure{Token: "token", BaseURL: "https://vssps.dev.azure.com/contoso/"}
+	assert.Equal(t, nil, client.Init())
+	assert.Equal(t, "https://vssps.dev.azure.com/contoso", client.BaseURL)
+	assert.Equal(t, fmt.Errorf("Token can't be empty"), (&Azure{}).Init())
+	organizationError := fmt.Errorf("Base URL must point to the organization, e.g. https://vssps.dev.azure.com/<organization>")
+	for _, baseURL := range []string{"", "https://vssps.dev.azure.com", "https://vssps.dev.azure.com/", "vssps.dev.azure.com/contoso"} {
+		t.Logf("Validating base URL %s", baseURL)
+		assert.Equal(t, organizationError, (&Azure{Token: "token", BaseURL: baseURL}).Init())
+	}
+}
+
+func TestAzureUserExists(t *testing.T) {
+	var groupRequests int
+	server := newAzureServer(t, &groupRequests)
+	defer server.Close()
+	client := &Azure{Token: "example_token", BaseURL: server.URL + "/contoso"}
+	assert.Nil(t, client.Init())
+	tests := []TestCase{
+		{Name: "existing user", Sample: "john@contoso.com", Expected: true},
+		{Name: "non-existent user", Sample: "jane@contoso.com", Expected: false},
+		{Name: "group isn't an user", Sample: `"[Contoso]\Web Team"`, Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.UserExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+}
+
+func TestAzureGroupExists(t *testing.T) {
+	var groupRequests int
+	server := newAzureServer(t, &groupRequests)
+	defer server.Close()
+	client := &Azure{Token: "example_token", BaseURL: server.URL + "/contoso/"}
+	assert.Nil(t, client.Init())
+	tests := []TestCase{
+		{Name: "existing group", Sample: `"[Contoso]\Web Team"`, Expected: true},
+		{Name: "group names are case insensitive", Sample: `[contoso]\web team`, Expected: true},
+		{Name: "non-existent group", Sample: `[Contoso]\Mobile`, Expected: false},
+		{Name: "user isn't a group", Sample: "john@contoso.com", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.GroupExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+	assert.Equal(t, 2, groupRequests, "groups are listed once")
+}
+
+func TestAzureUnauthorized(t *testing.T) {
+	var groupRequests int
+	server := newAzureServer(t, &groupRequests)
+	defer server.Close()
+	client := &Azure{Token: "wrong_token", BaseURL: server.URL + "/contoso"}
+	assert.Nil(t, client.Init())
+	valid, err := client.UserExists("john@contoso.com")
+	assert.Error(t, err)
+	assert.Equal(t, false, valid)
+}
@@ -0,0 +1,321 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BitbucketClientInterface interface implements the Bitbucket Client
+//
+//go:generate mockgen -source=bitbucket_client.go -destination=bitbucket_client_mock.go -package=providers
+type BitbucketClientInterface interface {
+	NewClient(token string, baseURL string)
+	ListUsers(filter string) ([]*BitbucketUser, error)
+	ListGroups(filter string) ([]string, error)
+}
+
+// BitbucketUser represents an user returned by the Bitbucket API
+type BitbucketUser struct {
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+// BitbucketCloudURL is the Bitbucket Cloud API, used when no URL is given
+const BitbucketCloudURL = "https://api.bitbucket.org"
+
+// Bitbucket represents a Bitbucket Cloud or Server/Data Center Client configuration.
+// Workspace is the Bitbucket Cloud workspace whose members and groups are owners
+type Bitbucket struct {
+	Token     string
+	BaseURL   string
+	Workspace string
+	Api       BitbucketClientInterface
+}
+
+// BitbucketClient implements a wrapper for calling the Bitbucket Server/Data Center REST API
+type BitbucketClient struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// bitbucketPage represents a paginated response of the Bitbucket API
+type bitbucketPage struct {
+	Values        json.RawMessage `json:"values"`
+	IsLastPage    bool            `json:"isLastPage"`
+	NextPageStart int             `json:"nextPageStart"`
+}
+
+// setBitbucketAuth authenticates the request, tokens written as user:app-password
+// use basic auth, access tokens are sent as bearer tokens
+func setBitbucketAuth(req *http.Request, token string) {
+	if user, password, ok := strings.Cut(token, ":"); ok {
+		req.SetBasicAuth(user, password)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+}
+
+// NewClient returns a new Bitbucket client
+func (c *BitbucketClient) NewClient(Token string, BaseURL string) {
+	c.token = Token
+	c.baseURL = strings.TrimSuffix(BaseURL, "/")
+	c.client = newHTTPClient(nil)
+}
+
+// list calls a paginated endpoint, decoding every page with decode
+func (c *BitbucketClient) list(path string, filter string, decode func(json.RawMessage) error) error {
+	start := 0
+	for {
+		query := url.Values{}
+		query.Set("filter", filter)
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", "25")
+		req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		setBitbucketAuth(req, c.token)
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		page := &bitbucketPage{}
+		err = json.NewDecoder(resp.Body).Decode(page)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if err := decode(page.Values); err != nil {
+			return err
+		}
+		if page.IsLastPage {
+			return nil
+		}
+		start = page.NextPageStart
+	}
+}
+
+// ListUsers returns a list of Bitbucket users matching the filter
+func (c *BitbucketClient) ListUsers(filter string) ([]*BitbucketUser, error) {
+	var users []*BitbucketUser
+	err := c.list("/rest/api/1.0/users", filter, func(values json.RawMessage) error {
+		var paginatedUsers []*BitbucketUser
+		if err := json.Unmarshal(values, &paginatedUsers); err != nil {
+			return err
+		}
+		users = append(users, paginatedUsers...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error searching for user %s: %s", filter, err)
+	}
+	return users, nil
+}
+
+// ListGroups returns a list of Bitbucket group names matching the filter, using the
+// groups endpoint available to licensed users instead of the admin one
+func (c *BitbucketClient) ListGroups(filter string) ([]string, error) {
+	var groups []string
+	err := c.list("/rest/api/1.0/groups", filter, func(values json.RawMessage) error {
+		var paginatedGroups []string
+		if err := json.Unmarshal(values, &paginatedGroups); err != nil {
+			return err
+		}
+		groups = append(groups, paginatedGroups...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Error searching for group %s: %s", filter, err)
+	}
+	return groups, nil
+}
+
+// BitbucketCloudClient implements a wrapper for calling the Bitbucket Cloud REST API.
+// Cloud can't search users, so the workspace members and groups are listed once and cached
+type BitbucketCloudClient struct {
+	Workspace string
+	token     string
+	baseURL   string
+	client    *http.Client
+	members   []*BitbucketUser
+	groups    []string
+}
+
+// NewClient returns a new Bitbucket Cloud client
+func (c *BitbucketCloudClient) NewClient(Token string, BaseURL string) {
+	c.token = Token
+	c.baseURL = strings.TrimSuffix(BaseURL, "/")
+	c.client = newHTTPClient(nil)
+}
+
+// get calls the Bitbucket Cloud API decoding the response into v
+func (c *BitbucketCloudClient) get(address string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	setBitbucketAuth(req, c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// workspaceURL returns the address of the workspace resource on the API version
+func (c *BitbucketCloudClient) workspaceURL(format string) (string, error) {
+	if c.Workspace == "" {
+		return "", fmt.Errorf("Workspace can't be empty on Bitbucket Cloud, set the workspace provider setting")
+	}
+	return c.baseURL + fmt.Sprintf(format, url.PathEscape(c.Workspace)), nil
+}
+
+// ListUsers returns the workspace members whose nickname, account id or display name is the filter
+func (c *BitbucketCloudClient) ListUsers(filter string) ([]*BitbucketUser, error) {
+	if c.members == nil {
+		address, err := c.workspaceURL("/2.0/workspaces/%s/members?pagelen=100")
+		if err != nil {
+			return nil, err
+		}
+		members := []*BitbucketUser{}
+		for address != "" {
+			page := struct {
+				Values []struct {
+					User struct {
+						Nickname    string `json:"nickname"`
+						AccountID   string `json:"account_id"`
+						DisplayName string `json:"display_name"`
+					} `json:"user"`
+				} `json:"values"`
+				Next string `json:"next"`
+			}{}
+			if err := c.get(address, &page); err != nil {
+				return nil, fmt.Errorf("Error searching for user %s: %s", filter, err)
+			}
+			for _, member := range page.Values {
+				members = append(members, &BitbucketUser{Name: member.User.Nickname, Slug: member.User.AccountID, DisplayName: member.User.DisplayName})
+			}
+			address = page.Next
+		}
+		c.members = members
+	}
+	var users []*BitbucketUser
+	for _, user := range c.members {
+		if strings.EqualFold(user.Name, filter) || user.Slug == filter || strings.EqualFold(user.DisplayName, filter) {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// ListGroups returns the names and slugs of the workspace groups matching the filter
+func (c *BitbucketCloudClient) ListGroups(filter string) ([]string, error) {
+	if c.groups == nil {
+		// the 2.0 API has no groups endpoint
+		address, err := c.workspaceURL("/1.0/groups/%s")
+		if err != nil {
+			return nil, err
+		}
+		var workspaceGroups []struct {
+			Name string `json:"name"`
+			Slug string `json:"slug"`
+		}
+		if err := c.get(address, &workspaceGroups); err != nil {
+			return nil, fmt.Errorf("Error searching for group %s: %s", filter, err)
+		}
+		groups := []string{}
+		for _, g := range workspaceGroups {
+			groups = append(groups, g.Name)
+			if g.Slug != g.Name {
+				groups = append(groups, g.Slug)
+			}
+		}
+		c.groups = groups
+	}
+	var groups []string
+	for _, group := range c.groups {
+		if strings.EqualFold(group, filter) {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// Init initializes the Bitbucket Client
+func (b *Bitbucket) Init() error {
+	if b.Token == "" {
+		return fmt.Errorf("Token can't be empty")
+	}
+	if b.BaseURL == "" {
+		b.BaseURL = BitbucketCloudURL
+	}
+	if b.Api == nil {
+		if b.Workspace != "" || b.BaseURL == BitbucketCloudURL {
+			b.Api = &BitbucketCloudClient{Workspace: b.Workspace}
+		} else {
+			b.Api = &BitbucketClient{}
+		}
+	}
+	b.Api.NewClient(b.Token, b.BaseURL)
+	return nil
+}
+
+// UserExists searches an user by name. Besides the username or e-mail,
+// the Code Owners add-on allows users by display name, written as "User Name"
+func (b *Bitbucket) UserExists(name string) (bool, error) {
+	if strings.HasPrefix(name, "{") {
+		return false, nil
+	}
+	displayName := ""
+	if unquoted := unquoteOwner(name); unquoted != name {
+		displayName = unquoted
+		name = unquoted
+	}
+	users, err := b.Api.ListUsers(name)
+	if err != nil {
+		return false, err
+	}
+	for _, user := range users {
+		if displayName != "" {
+			if user.DisplayName == displayName {
+				return true, nil
+			}
+			continue
+		}
+		if user.Name == name || user.Slug == name || strings.EqualFold(user.EmailAddress, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GroupExists searches a group by name. The Code Owners add-on writes groups as {group}
+func (b *Bitbucket) GroupExists(name string) (bool, error) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "{"), "}")
+	groups, err := b.Api.ListGroups(name)
+	if err != nil {
+		return false, err
+	}
+	for _, group := range groups {
+		if group == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitbucketInitSucessful(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockBitbucketClient := NewMockBitbucketClientInterface(mockCtrl)
+	client := &Bitbucket{
+		Token: "Token",
+		Api:   MockBitbucketClient,
+	}
+	MockBitbucketClient.EXPECT().NewClient(client.Token, BitbucketCloudURL).Times(1)
+	assert.Equal(t, nil, client.Init())
+}
+
+func TestBitbucketInitFlavor(t *testing.T) {
+	tests := []TestCase{
+		{Name: "cloud by default", Sample: &Bitbucket{Token: "xyz"}, Expected: true},
+		{Name: "cloud with workspace", Sample: &Bitbucket{Token: "xyz", BaseURL: "https://bitbucket.example.org", Workspace: "acme"}, Expected: true},
+		{Name: "server", Sample: &Bitbucket{Token: "xyz", BaseURL: "https://bitbucket.example.org"}, Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		client := test.Sample.(*Bitbucket)
+		assert.Nil(t, client.Init())
+		_, cloud := client.Api.(*BitbucketCloudClient)
+		assert.Equal(t, test.Expected.(bool), cloud)
+	}
+}
+
+func TestBitbucketInitMissingToken(t *testing.T) {
+	client := &Bitbucket{
+		BaseURL: "BaseURL",
+	}
+	assert.Error(t, client.Init(), "Token can't be empty")
+}
+
+func TestBitbucketUserExists(t *testing.T) {
+	users := []*BitbucketUser{
+		{Name: "jsmith", Slug: "jsmith", DisplayName: "John Smith", EmailAddress: "John.Smith@example.com"},
+	}
+	tests := []TestCase{
+		{Name: "by username", Sample: map[string]string{"Owner": "jsmith", "Filter": "jsmith"}, Expected: true},
+		{Name: "by e-mail", Sample: map[string]string{"Owner": "john.smith@example.com", "Filter": "john.smith@example.com"}, Expected: true},
+		{Name: "by display name", Sample: map[string]string{"Owner": `"John Smith"`, "Filter": "John Smith"}, Expected: true},
+		{Name: "display name must match exactly", Sample: map[string]string{"Owner": `"jsmith"`, "Filter": "jsmith"}, Expected: false},
+		{Name: "unknown user", Sample: map[string]string{"Owner": "jdoe", "Filter": "jdoe"}, Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		mockCtrl := gomock.NewController(t)
+		MockBitbucketClient := NewMockBitbucketClientInterface(mockCtrl)
+		client := &Bitbucket{
+			Token:   "example_token",
+			BaseURL: "example_url",
+			Api:     MockBitbucketClient,
+		}
+		sample := test.Sample.(map[string]string)
+		MockBitbucketClient.EXPECT().ListUsers(sample["Filter"]).Return(users, nil).Times(1)
+		valid, err := client.UserExists(sample["Owner"])
+		assert.Equal(t, nil, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+		mockCtrl.Finish()
+	}
+}
+
+func TestBitbucketUserExistsGroupSyntax(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	client := &Bitbucket{
+		Token: "example_token",
+		Api:   NewMockBitbucketClientInterface(mockCtrl),
+	}
+	valid, err := client.UserExists("{developers}")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, valid)
+}
+
+func TestBitbucketGroupExists(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockBitbucketClient := NewMockBitbucketClientInterface(mockCtrl)
+	client := &Bitbucket{
+		Token: "example_token",
+		Api:   MockBitbucketClient,
+	}
+	MockBitbucketClient.EXPECT().ListGroups("developers").Return([]string{"developers-ops", "developers"}, nil).Times(1)
+	valid, err := client.GroupExists("{developers}")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, valid)
+}
+
+func TestBitbucketGroupExistsFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockBitbucketClient := NewMockBitbucketClientInterface(mockCtrl)
+	client := &Bitbucket{
+		Token: "example_token",
+		Api:   MockBitbucketClient,
+	}
+	MockBitbucketClient.EXPECT().ListGroups("developers").Return(nil, fmt.Errorf("Error searching for group developers")).Times(1)
+	valid, err := client.GroupExists("developers")
+	assert.Equal(t, fmt.Errorf("Error searching for group developers"), err)
+	assert.Equal(t, false, valid)
+}
+
+func TestBitbucketClientPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer example_token", r.Header.Get("Authorization"))
+		assert.Equal(t, "/rest/api/1.0/users", r.URL.Path)
+		assert.Equal(t, "john", r.URL.Query().Get("filter"))
+		if r.URL.Query().Get("start") == "0" {
+			fmt.Fprint(w, `{"values":[{"name":"john1"}],"isLastPage":false,"nextPageStart":1}`)
+		} else {
+			fmt.Fprint(w, `{"values":[{"name":"john2"}],"isLastPage":true}`)
+		}
+	}))
+	defer server.Close()
+	client := &BitbucketClient{}
+	client.NewClient("example_token", server.URL)
+	users, err := client.ListUsers("john")
+	assert.Nil(t, err)
+	assert.Equal(t, []*BitbucketUser{{Name: "john1"}, {Name: "john2"}}, users)
+}
+
+func TestBitbucketClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	client := &BitbucketClient{}
+	client.NewClient("example_token", server.URL)
+	groups, err := client.ListGroups("developers")
+	assert.Error(t, err)
+	assert.Nil(t, groups)
+}
+
+func TestBitbucketClientGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/1.0/groups", r.URL.Path)
+		fmt.Fprint(w, `{"values":["developers","developers-ops"],"isLastPage":true}`)
+	}))
+	defer server.Close()
+	client := &BitbucketClient{}
+	client.NewClient("example_token", server.URL)
+	groups, err := client.ListGroups("developers")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"developers", "developers-ops"}, groups)
+}
+
+// newBitbucketCloudServer answers the workspace members, on two pages, and groups endpoints
+func newBitbucketCloudServer(t *testing.T, requests *int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		if user, password, _ := r.BasicAuth(); user != "jsmith" || password != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"type":"error"}`)
+			return
+		}
+		switch {
+		case r.URL.Path == "/2.0/workspaces/acme/members" && r.URL.Query().Get("page") == "":
+			fmt.Fprintf(w, `{"values":[{"user":{"nickname":"jsmith","account_id":"557058:1","display_name":"John Smith"}}],"next":"%s/2.0/workspaces/acme/members?page=2"}`, server.URL)
+		case r.URL.Path == "/2.0/workspaces/acme/members":
+			fmt.Fprint(w, `{"values":[{"user":{"nickname":"jdoe","account_id":"557058:2","display_name":"Jane Doe"}}]}`)
+		case r.URL.Path == "/1.0/groups/acme":
+			fmt.Fprint(w, `[{"name":"Developers","slug":"developers"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestBitbucketCloud(t *testing.T) {
+	var requests int
+	server := newBitbucketCloudServer(t, &requests)
+	defer server.Close()
+	provider, err := InitProviderWithSettings("bitbucket", "jsmith:app-password", server.URL, Settings{"workspace": "acme"})
+	assert.Nil(t, err)
+	tests := []TestCase{
+		{Name: "member by nickname", Sample: "jsmith", Expected: []bool{true, false}},
+		{Name: "member on the second page", Sample: "jdoe", Expected: []bool{true, false}},
+		{Name: "member by display name", Sample: `"Jane Doe"`, Expected: []bool{true, false}},
+		{Name: "group by name", Sample: "{Developers}", Expected: []bool{false, true}},
+		{Name: "group by slug", Sample: "{developers}", Expected: []bool{false, true}},
+		{Name: "unknown owner", Sample: "someone", Expected: []bool{false, false}},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		expected := test.Expected.([]bool)
+		user, err := provider.UserExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, expected[0], user)
+		group, err := provider.GroupExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, expected[1], group)
+	}
+	assert.Equal(t, 3, requests, "members and groups are listed once")
+
+	provider, err = InitProviderWithSettings("bitbucket", "wrong:password", server.URL, Settings{"workspace": "acme"})
+	assert.Nil(t, err)
+	_, err = provider.UserExists("jsmith")
+	assert.Equal(t, fmt.Errorf("Error searching for user jsmith: unexpected status 401"), err)
+
+	provider, err = InitProviderWithSettings("bitbucket", "jsmith:app-password", "", nil)
+	assert.Nil(t, err)
+	_, err = provider.GroupExists("{developers}")
+	assert.Equal(t, fmt.Errorf("Workspace can't be empty on Bitbucket Cloud, set the workspace provider setting"), err)
+}
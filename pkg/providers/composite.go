@@ -0,0 +1,214 @@
+package providers
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// Strategies used by the Composite provider to combine the answers of its providers
+const (
+	// FirstMatch asks the providers in order, stopping at the first one that knows the name
+	FirstMatch = "first-match"
+	// AllMustAgree requires every provider to know the name
+	AllMustAgree = "all-must-agree"
+	// Any asks every provider, tolerating errors as long as one of them knows the name
+	Any = "any"
+)
+
+// ListStrategies returns the strategies supported by the Composite provider
+func ListStrategies() []string {
+	return []string{FirstMatch, AllMustAgree, Any}
+}
+
+// Composite represents a chain of providers combined with a strategy
+type Composite struct {
+	Strategy  string
+	Providers []Provider
+}
+
+// CompositeConfig represents the configuration file of a Composite provider
+type CompositeConfig struct {
+	Strategy  string                  `yaml:"strategy"`
+	Providers []CompositeMemberConfig `yaml:"providers"`
+}
+
+// CompositeMemberConfig represents a provider chained by a Composite provider.
+// TokenEnv names the environment variable holding the token, so it isn't stored on the file
+type CompositeMemberConfig struct {
+	Name     string   `yaml:"name"`
+	Token    string   `yaml:"token"`
+	TokenEnv string   `yaml:"token-env"`
+	BaseURL  string   `yaml:"base-url"`
+	Settings Settings `yaml:"settings"`
+}
+
+// LoadComposite initializes a Composite provider from a YAML configuration file
+func LoadComposite(filename string) (*Composite, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open provider configuration: %s", err)
+	}
+	config := &CompositeConfig{}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, fmt.Errorf("Invalid provider configuration: %s", err)
+	}
+	composite := &Composite{Strategy: config.Strategy}
+	for _, member := range config.Providers {
+		token := member.Token
+		if member.TokenEnv != "" {
+			token = os.Getenv(member.TokenEnv)
+		}
+		p, err := InitProviderWithSettings(member.Name, token, member.BaseURL, member.Settings)
+		if err != nil {
+			// the providers already started, e.g. plugin processes, aren't used
+			composite.Close()
+			return nil, fmt.Errorf("Could not initialize provider %s: %s", member.Name, err)
+		}
+		composite.Providers = append(composite.Providers, p)
+	}
+	if err := composite.Init(); err != nil {
+		composite.Close()
+		return nil, err
+	}
+	return composite, nil
+}
+
+// Init checks the Composite provider configuration
+func (c *Composite) Init() error {
+	if c.Strategy == "" {
+		c.Strategy = FirstMatch
+	}
+	switch c.Strategy {
+	case FirstMatch, AllMustAgree, Any:
+	default:
+		return fmt.Errorf("Invalid strategy %s, valid strategies: %v", c.Strategy, ListStrategies())
+	}
+	if len(c.Providers) == 0 {
+		return fmt.Errorf("Composite provider needs at least one provider")
+	}
+	return nil
+}
+
+// Close closes the providers that hold resources, e.g. plugin processes,
+// returning the first error
+func (c *Composite) Close() error {
+	var firstErr error
+	for _, p := range c.Providers {
+		if closer, ok := p.(io.Closer); ok {
+			if err := closer.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// combine asks the providers using the strategy
+func (c *Composite) combine(providers []Provider, exists func(Provider) (bool, error)) (bool, error) {
+	var lastErr error
+	for _, p := range providers {
+		found, err := exists(p)
+		if err != nil {
+			if c.Strategy != Any {
+				return false, err
+			}
+			log.Warnf("Ignoring provider error: %s", err)
+			lastErr = err
+			continue
+		}
+		if c.Strategy == AllMustAgree {
+			if !found {
+				return false, nil
+			}
+			continue
+		}
+		if found {
+			return true, nil
+		}
+	}
+	if c.Strategy == AllMustAgree {
+		return true, nil
+	}
+	return false, lastErr
+}
+
+// UserExists asks the providers if the user exists
+func (c *Composite) UserExists(name string) (bool, error) {
+	return c.combine(c.Providers, func(p Provider) (bool, error) {
+		return p.UserExists(name)
+	})
+}
+
+// GroupExists asks the providers if the group exists
+func (c *Composite) GroupExists(name string) (bool, error) {
+	return c.combine(c.Providers, func(p Provider) (bool, error) {
+		return p.GroupExists(name)
+	})
+}
+
+// IsMember asks the providers able to check memberships if the user belongs to the group
+func (c *Composite) IsMember(username string, group string) (bool, error) {
+	var checkers []Provider
+	for _, p := range c.Providers {
+		if _, ok := p.(MembershipChecker); ok {
+			checkers = append(checkers, p)
+		}
+	}
+	if len(checkers) == 0 {
+		return false, fmt.Errorf("None of the providers can check group memberships")
+	}
+	return c.combine(checkers, func(p Provider) (bool, error) {
+		return p.(MembershipChecker).IsMember(username, group)
+	})
+}
+
+// ResolveOwners asks the providers about many names at once, using the batch API of
+// the providers that have one. Names already known aren't asked again unless every
+// provider must agree
+func (c *Composite) ResolveOwners(names []string) (map[string]OwnerInfo, error) {
+	owners := make(map[string]OwnerInfo)
+	for _, name := range names {
+		owners[name] = OwnerInfo{User: c.Strategy == AllMustAgree, Group: c.Strategy == AllMustAgree}
+	}
+	pending := names
+	var lastErr error
+	for _, p := range c.Providers {
+		if len(pending) == 0 {
+			break
+		}
+		found, err := ResolveOwners(p, pending)
+		if err != nil {
+			if c.Strategy != Any {
+				return nil, err
+			}
+			log.Warnf("Ignoring provider error: %s", err)
+			lastErr = err
+			continue
+		}
+		var unknown []string
+		for _, name := range pending {
+			info := owners[name]
+			if c.Strategy == AllMustAgree {
+				info.User = info.User && found[name].User
+				info.Group = info.Group && found[name].Group
+				unknown = append(unknown, name)
+			} else {
+				info.User = info.User || found[name].User
+				info.Group = info.Group || found[name].Group
+				if !info.User && !info.Group {
+					unknown = append(unknown, name)
+				}
+			}
+			owners[name] = info
+		}
+		pending = unknown
+	}
+	if c.Strategy != AllMustAgree && len(pending) > 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return owners, nil
+}
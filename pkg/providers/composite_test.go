@@ -0,0 +1,263 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticProvider answers from fixed lists, counting how many times it was asked
+type staticProvider struct {
+	users  []string
+	groups []string
+	err    error
+	calls  int
+}
+
+func (s *staticProvider) Init() error {
+	return nil
+}
+
+func (s *staticProvider) contains(list []string, name string) (bool, error) {
+	s.calls++
+	if s.err != nil {
+		return false, s.err
+	}
+	for _, item := range list {
+		if item == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *staticProvider) UserExists(name string) (bool, error) {
+	return s.contains(s.users, name)
+}
+
+func (s *staticProvider) GroupExists(name string) (bool, error) {
+	return s.contains(s.groups, name)
+}
+
+func TestCompositeUserExists(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "first-match finds the user on the second provider",
+			Sample:   map[string]interface{}{"Strategy": FirstMatch, "User": "user2"},
+			Expected: ReturnWithError{Value: true},
+		},
+		{
+			Name:     "first-match doesn't find the user",
+			Sample:   map[string]interface{}{"Strategy": FirstMatch, "User": "user3"},
+			Expected: ReturnWithError{Value: false},
+		},
+		{
+			Name:     "all-must-agree with user on every provider",
+			Sample:   map[string]interface{}{"Strategy": AllMustAgree, "User": "user1"},
+			Expected: ReturnWithError{Value: true},
+		},
+		{
+			Name:     "all-must-agree with user on a single provider",
+			Sample:   map[string]interface{}{"Strategy": AllMustAgree, "User": "user2"},
+			Expected: ReturnWithError{Value: false},
+		},
+		{
+			Name:     "any finds the user on the second provider",
+			Sample:   map[string]interface{}{"Strategy": Any, "User": "user2"},
+			Expected: ReturnWithError{Value: true},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.(map[string]interface{})
+		expected := test.Expected.(ReturnWithError)
+		c := &Composite{
+			Strategy: sample["Strategy"].(string),
+			Providers: []Provider{
+				&staticProvider{users: []string{"user1"}},
+				&staticProvider{users: []string{"user1", "user2"}},
+			},
+		}
+		assert.Nil(t, c.Init())
+		valid, err := c.UserExists(sample["User"].(string))
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Value.(bool), valid)
+	}
+}
+
+func TestCompositeFirstMatchStopsEarly(t *testing.T) {
+	fallback := &staticProvider{groups: []string{"group1"}}
+	c := &Composite{
+		Strategy:  FirstMatch,
+		Providers: []Provider{&staticProvider{groups: []string{"group1"}}, fallback},
+	}
+	assert.Nil(t, c.Init())
+	valid, err := c.GroupExists("group1")
+	assert.Nil(t, err)
+	assert.Equal(t, true, valid)
+	assert.Equal(t, 0, fallback.calls)
+}
+
+func TestCompositeErrors(t *testing.T) {
+	broken := &staticProvider{err: fmt.Errorf("connection refused")}
+	working := &staticProvider{users: []string{"user1"}}
+	for _, strategy := range []string{FirstMatch, AllMustAgree} {
+		t.Logf("Validating strategy %s", strategy)
+		c := &Composite{Strategy: strategy, Providers: []Provider{broken, working}}
+		valid, err := c.UserExists("user1")
+		assert.Equal(t, fmt.Errorf("connection refused"), err)
+		assert.Equal(t, false, valid)
+	}
+	c := &Composite{Strategy: Any, Providers: []Provider{broken, working}}
+	valid, err := c.UserExists("user1")
+	assert.Nil(t, err)
+	assert.Equal(t, true, valid)
+	valid, err = c.UserExists("user2")
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+	assert.Equal(t, false, valid)
+}
+
+func TestCompositeInit(t *testing.T) {
+	assert.Error(t, (&Composite{}).Init())
+	assert.Error(t, (&Composite{Strategy: "non-existent", Providers: []Provider{&staticProvider{}}}).Init())
+	c := &Composite{Providers: []Provider{&staticProvider{}}}
+	assert.Nil(t, c.Init())
+	assert.Equal(t, FirstMatch, c.Strategy)
+}
+
+func TestCompositeIsMember(t *testing.T) {
+	c := &Composite{Providers: []Provider{&staticProvider{}}}
+	_, err := c.IsMember("user1", "group1")
+	assert.Error(t, err)
+}
+
+func TestLoadComposite(t *testing.T) {
+	defer filet.CleanUp(t)
+	server := newLDAPServer(t)
+	defer server.Close()
+	dir := filet.TmpDir(t, "")
+	t.Setenv("TEST_LDAP_PASSWORD", "secret")
+	t.Setenv("CI_JOB_TOKEN", "")
+	valid := filepath.Join(dir, "valid.yaml")
+	filet.File(t, valid, fmt.Sprintf(`strategy: all-must-agree
+providers:
+  - name: ldap
+    token-env: TEST_LDAP_PASSWORD
+    base-url: %s
+    settings:
+      user-base-dn: ou=people,dc=example,dc=org
+  - name: gitlab
+    token: xyz
+`, server.URL()))
+	c, err := LoadComposite(valid)
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Equal(t, AllMustAgree, c.Strategy)
+	assert.Equal(t, 2, len(c.Providers))
+	assert.Equal(t, "secret", c.Providers[0].(*LDAP).Token)
+	assert.Equal(t, "ou=people,dc=example,dc=org", c.Providers[0].(*LDAP).UserBaseDN)
+
+	missingToken := filepath.Join(dir, "missing-token.yaml")
+	filet.File(t, missingToken, `providers:
+  - name: gitlab
+    token-env: TEST_NON_EXISTENT_TOKEN
+`)
+	_, err = LoadComposite(missingToken)
+	assert.Error(t, err)
+}
+
+func TestCompositeResolveOwners(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:   "first-match",
+			Sample: FirstMatch,
+			Expected: map[string]OwnerInfo{
+				"user1":  {User: true},
+				"user2":  {User: true},
+				"group1": {Group: true},
+				"other":  {},
+			},
+		},
+		{
+			Name:   "all-must-agree",
+			Sample: AllMustAgree,
+			Expected: map[string]OwnerInfo{
+				"user1":  {User: true},
+				"user2":  {},
+				"group1": {},
+				"other":  {},
+			},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		batch := &batchProvider{staticProvider: staticProvider{users: []string{"user1", "user2"}}}
+		c := &Composite{
+			Strategy:  test.Sample.(string),
+			Providers: []Provider{&staticProvider{users: []string{"user1"}, groups: []string{"group1"}}, batch},
+		}
+		owners, err := c.ResolveOwners([]string{"user1", "user2", "group1", "other"})
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected, owners)
+		assert.Equal(t, 1, batch.batches)
+	}
+}
+
+func TestCompositeResolveOwnersErrors(t *testing.T) {
+	broken := &staticProvider{err: fmt.Errorf("connection refused")}
+	working := &staticProvider{users: []string{"user1"}}
+	c := &Composite{Strategy: FirstMatch, Providers: []Provider{broken, working}}
+	_, err := c.ResolveOwners([]string{"user1"})
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+	c = &Composite{Strategy: Any, Providers: []Provider{broken, working}}
+	owners, err := c.ResolveOwners([]string{"user1"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]OwnerInfo{"user1": {User: true}}, owners)
+	_, err = c.ResolveOwners([]string{"user1", "user2"})
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+}
+
+// closingProvider records when it is closed
+type closingProvider struct {
+	staticProvider
+	closed bool
+	err    error
+}
+
+func (c *closingProvider) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestCompositeClose(t *testing.T) {
+	first := &closingProvider{err: fmt.Errorf("broken pipe")}
+	second := &closingProvider{}
+	c := &Composite{Providers: []Provider{first, &staticProvider{}, second}}
+	assert.Equal(t, fmt.Errorf("broken pipe"), c.Close())
+	assert.Equal(t, true, first.closed)
+	assert.Equal(t, true, second.closed, "every provider is closed even after an error")
+}
+
+func TestLoadCompositeClosesOnError(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	// the plugin creates the marker once stdin is closed and it exits
+	marker := filepath.Join(dir, "closed")
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 %q -test.run=^$ \"$@\"\n: > %q\n", testPluginEnv, os.Args[0], marker)
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"static"), []byte(script), 0755))
+	t.Setenv("PATH", dir)
+	config := filepath.Join(dir, "config.yaml")
+	filet.File(t, config, `providers:
+  - name: static
+    token: xyz
+  - name: non-existent
+`)
+	_, err := LoadComposite(config)
+	assert.Error(t, err)
+	_, err = os.Stat(marker)
+	assert.Nil(t, err, "the plugin started before the error is closed")
+}
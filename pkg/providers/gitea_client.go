@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Gitea represents a Gitea/Forgejo Client configuration
+type Gitea struct {
+	Token   string
+	BaseURL string
+	client  *http.Client
+}
+
+// Init initializes the Gitea Client
+func (g *Gitea) Init() error {
+	if g.Token == "" {
+		return fmt.Errorf("Token can't be empty")
+	}
+	if g.BaseURL == "" {
+		g.BaseURL = "https://gitea.com"
+	}
+	g.BaseURL = strings.TrimSuffix(g.BaseURL, "/")
+	if g.client == nil {
+		g.client = newHTTPClient(nil)
+	}
+	return nil
+}
+
+// get calls the Gitea API, decoding the response into v.
+// Returns false when the resource doesn't exist
+func (g *Gitea) get(path string, query url.Values, v interface{}) (bool, error) {
+	endpoint := g.BaseURL + "/api/v1" + path
+	if query != nil {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "token "+g.Token)
+	req.Header.Set("Accept", "application/json")
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if v == nil {
+		return true, nil
+	}
+	return true, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// teamsPageSize is the number of teams asked on each page of the teams search,
+// and teamsMaxPages the number of pages read before giving up
+const (
+	teamsPageSize = 50
+	teamsMaxPages = 20
+)
+
+// UserExists checks if the user exists. Organizations are answered by the users
+// API as well, so names of organizations aren't users
+func (g *Gitea) UserExists(name string) (bool, error) {
+	org, err := g.get("/orgs/"+url.PathEscape(name), nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("Error searching for user %s: %s", name, err)
+	}
+	if org {
+		return false, nil
+	}
+	exists, err := g.get("/users/"+url.PathEscape(name), nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("Error searching for user %s: %s", name, err)
+	}
+	return exists, nil
+}
+
+// GroupExists checks if the organization, or the team when written as org/team, exists
+func (g *Gitea) GroupExists(name string) (bool, error) {
+	org, team, isTeam := strings.Cut(name, "/")
+	if !isTeam {
+		exists, err := g.get("/orgs/"+url.PathEscape(org), nil, nil)
+		if err != nil {
+			return false, fmt.Errorf("Error searching for group %s: %s", name, err)
+		}
+		return exists, nil
+	}
+	// the search matches teams containing the name, so pages are read until the
+	// team is found or an empty page, since servers may cap the page size
+	for page := 1; page <= teamsMaxPages; page++ {
+		result := struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		}{}
+		query := url.Values{}
+		query.Set("q", team)
+		query.Set("page", fmt.Sprint(page))
+		query.Set("limit", fmt.Sprint(teamsPageSize))
+		exists, err := g.get("/orgs/"+url.PathEscape(org)+"/teams/search", query, &result)
+		if err != nil {
+			return false, fmt.Errorf("Error searching for group %s: %s", name, err)
+		}
+		if !exists || len(result.Data) == 0 {
+			return false, nil
+		}
+		for _, t := range result.Data {
+			if strings.EqualFold(t.Name, team) {
+				return true, nil
+			}
+		}
+	}
+	return false, fmt.Errorf("Error searching for group %s: more than %d pages of teams match", name, teamsMaxPages)
+}
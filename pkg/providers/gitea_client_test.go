@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newGiteaServer returns a Gitea stand-in with the user1 user, the org1 organization,
+// also answered by the users API like Gitea does, and its developers team on the second page
+func newGiteaServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/users/user1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"user1"}`)
+	})
+	mux.HandleFunc("/api/v1/users/org1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"org1"}`)
+	})
+	mux.HandleFunc("/api/v1/orgs/org1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"username":"org1"}`)
+	})
+	mux.HandleFunc("/api/v1/orgs/org1/teams/search", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "50", r.URL.Query().Get("limit"))
+		switch {
+		case r.URL.Query().Get("q") != "developers" && r.URL.Query().Get("q") != "ops":
+			fmt.Fprint(w, `{"ok":true,"data":[]}`)
+		case r.URL.Query().Get("page") == "1":
+			fmt.Fprint(w, `{"ok":true,"data":[{"name":"developers-ops"}]}`)
+		case r.URL.Query().Get("page") == "2":
+			fmt.Fprint(w, `{"ok":true,"data":[{"name":"Developers"}]}`)
+		default:
+			fmt.Fprint(w, `{"ok":true,"data":[]}`)
+		}
+	})
+	// endless answers every page, like servers ignoring the page parameter
+	mux.HandleFunc("/api/v1/orgs/endless/teams/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"ok":true,"data":[{"name":"developers-ops"}]}`)
+	})
+	mux.HandleFunc("/api/v1/orgs/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token example_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestGiteaInit(t *testing.T) {
+	client := &Gitea{Token: "token"}
+	assert.Equal(t, nil, client.Init())
+	assert.Equal(t, "https://gitea.com", client.BaseURL)
+	assert.Error(t, (&Gitea{}).Init(), "Token can't be empty")
+}
+
+func TestGiteaUserExists(t *testing.T) {
+	server := newGiteaServer(t)
+	defer server.Close()
+	client := &Gitea{Token: "example_token", BaseURL: server.URL + "/"}
+	assert.Nil(t, client.Init())
+	tests := []TestCase{
+		{Name: "existing user", Sample: "user1", Expected: true},
+		{Name: "non-existent user", Sample: "user2", Expected: false},
+		{Name: "organization isn't an user", Sample: "org1", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.UserExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+}
+
+func TestGiteaGroupExists(t *testing.T) {
+	server := newGiteaServer(t)
+	defer server.Close()
+	client := &Gitea{Token: "example_token", BaseURL: server.URL}
+	assert.Nil(t, client.Init())
+	tests := []TestCase{
+		{Name: "existing organization", Sample: "org1", Expected: true},
+		{Name: "non-existent organization", Sample: "org2", Expected: false},
+		{Name: "existing team on the second page", Sample: "org1/developers", Expected: true},
+		{Name: "non-existent team matching other teams", Sample: "org1/ops", Expected: false},
+		{Name: "team on non-existent organization", Sample: "org2/developers", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.GroupExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+}
+
+func TestGiteaErrors(t *testing.T) {
+	server := newGiteaServer(t)
+	defer server.Close()
+	client := &Gitea{Token: "example_token", BaseURL: server.URL}
+	assert.Nil(t, client.Init())
+	valid, err := client.GroupExists("broken")
+	assert.Error(t, err)
+	assert.Equal(t, false, valid)
+	valid, err = client.GroupExists("endless/developers")
+	assert.Equal(t, fmt.Errorf("Error searching for group endless/developers: more than 20 pages of teams match"), err)
+	assert.Equal(t, false, valid)
+
+	unauthorized := &Gitea{Token: "wrong_token", BaseURL: server.URL}
+	assert.Nil(t, unauthorized.Init())
+	valid, err = unauthorized.UserExists("user1")
+	assert.Error(t, err)
+	assert.Equal(t, false, valid)
+}
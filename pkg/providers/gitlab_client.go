@@ -1,24 +1,51 @@
 package providers
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/xanzy/go-gitlab"
 )
 
+// Token types accepted by the Gitlab provider
+const (
+	// GitlabPrivateToken is a personal, project or group access token
+	GitlabPrivateToken = "private"
+	// GitlabJobToken is the CI_JOB_TOKEN of a GitLab CI job
+	GitlabJobToken = "job"
+	// GitlabOAuthToken is an OAuth2 access token
+	GitlabOAuthToken = "oauth"
+)
+
 // GitlabClient interface implements the Gitlab Client
 //go:generate mockgen -destination=gitlab_client_mock.go -package=providers github.com/topfreegames/codeowners-verifier/pkg/providers ClientInterface
 type ClientInterface interface {
-	NewClient(token string, baseURL string)
+	NewClient(token string, baseURL string, tokenType string, httpClient *http.Client) error
 	ListUsers(name string) ([]*gitlab.User, error)
 	ListGroups(name string) ([]*gitlab.Group, error)
+	ListGroupMembers(group string, username string) ([]*gitlab.GroupMember, error)
+	GetMergeRequest(project string, id int) (*gitlab.MergeRequest, error)
+	ListMergeRequestDiffs(project string, id int) ([]*gitlab.Diff, error)
+	GetMergeRequestApprovals(project string, id int) (*gitlab.MergeRequestApprovals, error)
 }
 
-// Gitlab represents a Gitlab Client configuration
+// Gitlab represents a Gitlab Client configuration. CAFile adds a CA bundle to the
+// system ones, CertFile and KeyFile are the client certificate used for mTLS
 type Gitlab struct {
-	Token   string
-	BaseURL string
-	Api     ClientInterface
+	Token     string
+	BaseURL   string
+	TokenType string
+	CAFile    string
+	CertFile  string
+	KeyFile   string
+	ProxyURL  string
+	Api       ClientInterface
 }
 
 // GitlabClient implements a wrapper for calling the gitlab library
@@ -26,9 +53,27 @@ type GitlabClient struct {
 	client *gitlab.Client
 }
 
-// NewClient returns a new Gitlab client
-func (c *GitlabClient) NewClient(Token string, BaseURL string) {
-	c.client, _ = gitlab.NewClient(Token, gitlab.WithBaseURL(BaseURL))
+// NewClient returns a new Gitlab client authenticating with the token type
+func (c *GitlabClient) NewClient(Token string, BaseURL string, TokenType string, HTTPClient *http.Client) error {
+	options := []gitlab.ClientOptionFunc{gitlab.WithBaseURL(BaseURL)}
+	if HTTPClient != nil {
+		options = append(options, gitlab.WithHTTPClient(HTTPClient))
+	}
+	var err error
+	switch TokenType {
+	case GitlabPrivateToken, "":
+		c.client, err = gitlab.NewClient(Token, options...)
+	case GitlabJobToken:
+		c.client, err = gitlab.NewJobClient(Token, options...)
+	case GitlabOAuthToken:
+		c.client, err = gitlab.NewOAuthClient(Token, options...)
+	default:
+		return fmt.Errorf("Invalid token type %s, valid types: %v", TokenType, []string{GitlabPrivateToken, GitlabJobToken, GitlabOAuthToken})
+	}
+	if err != nil {
+		return fmt.Errorf("Couldn't create Gitlab client: %s", err)
+	}
+	return nil
 }
 
 // ListUsers returns a list of Gitlab users matching the name
@@ -83,19 +128,185 @@ func (c *GitlabClient) ListGroups(name string) ([]*gitlab.Group, error) {
 	return groups, nil
 }
 
-// Init initializes the Gitlab Client
+// httpClient returns the HTTP client used to reach Gitlab, or nil when the
+// default one is enough
+func (g *Gitlab) httpClient() (*http.Client, error) {
+	if g.CAFile == "" && g.CertFile == "" && g.KeyFile == "" && g.ProxyURL == "" {
+		if WrapTransport == nil {
+			return nil, nil
+		}
+		return newHTTPClient(nil), nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if g.ProxyURL != "" {
+		proxy, err := url.Parse(g.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if g.CAFile != "" {
+		content, err := os.ReadFile(g.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't read CA bundle: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("No certificates found on CA bundle %s", g.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	if g.CertFile != "" || g.KeyFile != "" {
+		if g.CertFile == "" || g.KeyFile == "" {
+			return nil, fmt.Errorf("Client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(g.CertFile, g.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't load client certificate: %s", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	}
+	return newHTTPClient(transport), nil
+}
+
+// ListGroupMembers returns the members of the group, including inherited ones, matching the username.
+// Returns no members when the group doesn't exist
+func (c *GitlabClient) ListGroupMembers(group string, username string) ([]*gitlab.GroupMember, error) {
+	opt := &gitlab.ListGroupMembersOptions{
+		Query: gitlab.String(username),
+		ListOptions: gitlab.ListOptions{
+			PerPage: 20,
+			Page:    1,
+		},
+	}
+	var members []*gitlab.GroupMember
+
+	for {
+		paginatedMembers, response, err := c.client.Groups.ListAllGroupMembers(group, opt)
+		if response != nil && response.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error listing members of group %s: %s", group, err)
+		}
+		members = append(members, paginatedMembers...)
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	return members, nil
+}
+
+// GetMergeRequest returns the merge request, without its changed files
+func (c *GitlabClient) GetMergeRequest(project string, id int) (*gitlab.MergeRequest, error) {
+	mr, _, err := c.client.MergeRequests.GetMergeRequest(project, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting merge request %d: %s", id, err)
+	}
+	return mr, nil
+}
+
+// ListMergeRequestDiffs returns every changed file of the merge request from the paginated
+// diffs API. Servers older than GitLab 15.7 don't have it, so the changes API is used
+// instead, failing when it leaves files out of a large merge request
+func (c *GitlabClient) ListMergeRequestDiffs(project string, id int) ([]*gitlab.Diff, error) {
+	opt := &gitlab.ListOptions{
+		PerPage: 100,
+		Page:    1,
+	}
+	var diffs []*gitlab.Diff
+
+	for {
+		req, err := c.client.NewRequest(http.MethodGet, fmt.Sprintf("projects/%s/merge_requests/%d/diffs", gitlab.PathEscape(project), id), opt, nil)
+		if err != nil {
+			return nil, fmt.Errorf("Error getting merge request %d diffs: %s", id, err)
+		}
+		var paginatedDiffs []*gitlab.Diff
+		response, err := c.client.Do(req, &paginatedDiffs)
+		if response != nil && response.StatusCode == http.StatusNotFound && opt.Page == 1 {
+			return c.listMergeRequestChanges(project, id)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error getting merge request %d diffs: %s", id, err)
+		}
+		diffs = append(diffs, paginatedDiffs...)
+		if response.NextPage == 0 {
+			break
+		}
+		opt.Page = response.NextPage
+	}
+
+	return diffs, nil
+}
+
+// listMergeRequestChanges returns the changed files of the merge request from the changes API
+func (c *GitlabClient) listMergeRequestChanges(project string, id int) ([]*gitlab.Diff, error) {
+	mr, _, err := c.client.MergeRequests.GetMergeRequestChanges(project, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting merge request %d changes: %s", id, err)
+	}
+	if mr.Overflow {
+		return nil, fmt.Errorf("Merge request %d has more changes than GitLab lists", id)
+	}
+	var diffs []*gitlab.Diff
+	for _, change := range mr.Changes {
+		diffs = append(diffs, &gitlab.Diff{
+			Diff:        change.Diff,
+			NewPath:     change.NewPath,
+			OldPath:     change.OldPath,
+			AMode:       change.AMode,
+			BMode:       change.BMode,
+			NewFile:     change.NewFile,
+			RenamedFile: change.RenamedFile,
+			DeletedFile: change.DeletedFile,
+		})
+	}
+	return diffs, nil
+}
+
+// GetMergeRequestApprovals returns the approvals of the merge request
+func (c *GitlabClient) GetMergeRequestApprovals(project string, id int) (*gitlab.MergeRequestApprovals, error) {
+	approvals, _, err := c.client.MergeRequestApprovals.GetConfiguration(project, id)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting merge request %d approvals: %s", id, err)
+	}
+	return approvals, nil
+}
+
+// Init initializes the Gitlab Client. Inside GitLab CI the job token and the
+// server URL are used when no token, token type or URL is given
 func (g *Gitlab) Init() error {
+	if g.Token == "" && g.TokenType == "" && os.Getenv("CI_JOB_TOKEN") != "" {
+		g.Token = os.Getenv("CI_JOB_TOKEN")
+		g.TokenType = GitlabJobToken
+		log.Warn("Using CI_JOB_TOKEN, job tokens can't call the /users and /groups search APIs used to validate owners, use a private token instead")
+	}
 	if g.Token == "" {
 		return fmt.Errorf("Token can't be empty")
 	}
+	if g.TokenType == "" {
+		g.TokenType = GitlabPrivateToken
+	}
+	if g.BaseURL == "" && os.Getenv("CI_SERVER_URL") != "" {
+		g.BaseURL = strings.TrimSuffix(os.Getenv("CI_SERVER_URL"), "/") + "/api/v4"
+	}
 	if g.BaseURL == "" {
 		g.BaseURL = "https://gitlab.com/api/v4"
 	}
+	httpClient, err := g.httpClient()
+	if err != nil {
+		return err
+	}
 	if g.Api == nil {
 		g.Api = &GitlabClient{}
 	}
-	g.Api.NewClient(g.Token, g.BaseURL)
-	return nil
+	return g.Api.NewClient(g.Token, g.BaseURL, g.TokenType, httpClient)
 }
 
 // SearchUser searches a user by name
@@ -125,3 +336,49 @@ func (g *Gitlab) GroupExists(name string) (bool, error) {
 	}
 	return false, nil
 }
+
+// IsMember checks if the user is a member of the group, directly or inherited from a parent group
+func (g *Gitlab) IsMember(username string, group string) (bool, error) {
+	members, err := g.Api.ListGroupMembers(group, username)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetMergeRequest returns the changed files and the approvers of the merge request
+func (g *Gitlab) GetMergeRequest(project string, id int) (*MergeRequest, error) {
+	details, err := g.Api.GetMergeRequest(project, id)
+	if err != nil {
+		return nil, err
+	}
+	diffs, err := g.Api.ListMergeRequestDiffs(project, id)
+	if err != nil {
+		return nil, err
+	}
+	approvals, err := g.Api.GetMergeRequestApprovals(project, id)
+	if err != nil {
+		return nil, err
+	}
+	mr := &MergeRequest{ID: id}
+	if details.Author != nil {
+		mr.Author = details.Author.Username
+	}
+	for _, change := range diffs {
+		mr.Files = append(mr.Files, change.NewPath)
+		if change.RenamedFile {
+			mr.Files = append(mr.Files, change.OldPath)
+		}
+	}
+	for _, approver := range approvals.ApprovedBy {
+		if approver.User != nil {
+			mr.Approvers = append(mr.Approvers, approver.User.Username)
+		}
+	}
+	return mr, nil
+}
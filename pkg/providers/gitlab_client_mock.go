@@ -7,6 +7,7 @@ package providers
 import (
 	gomock "github.com/golang/mock/gomock"
 	gitlab "github.com/xanzy/go-gitlab"
+	http "net/http"
 	reflect "reflect"
 )
 
@@ -34,15 +35,17 @@ func (m *MockClientInterface) EXPECT() *MockClientInterfaceMockRecorder {
 }
 
 // NewClient mocks base method
-func (m *MockClientInterface) NewClient(Token, BaseURL string) {
+func (m *MockClientInterface) NewClient(token, baseURL, tokenType string, httpClient *http.Client) error {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "NewClient", Token, BaseURL)
+	ret := m.ctrl.Call(m, "NewClient", token, baseURL, tokenType, httpClient)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
 // NewClient indicates an expected call of NewClient
-func (mr *MockClientInterfaceMockRecorder) NewClient(Token, BaseURL interface{}) *gomock.Call {
+func (mr *MockClientInterfaceMockRecorder) NewClient(token, baseURL, tokenType, httpClient interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewClient", reflect.TypeOf((*MockClientInterface)(nil).NewClient), Token, BaseURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewClient", reflect.TypeOf((*MockClientInterface)(nil).NewClient), token, baseURL, tokenType, httpClient)
 }
 
 // ListUsers mocks base method
@@ -74,3 +77,63 @@ func (mr *MockClientInterfaceMockRecorder) ListGroups(name interface{}) *gomock.
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockClientInterface)(nil).ListGroups), name)
 }
+
+// ListGroupMembers mocks base method
+func (m *MockClientInterface) ListGroupMembers(group, username string) ([]*gitlab.GroupMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", group, username)
+	ret0, _ := ret[0].([]*gitlab.GroupMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers
+func (mr *MockClientInterfaceMockRecorder) ListGroupMembers(group, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockClientInterface)(nil).ListGroupMembers), group, username)
+}
+
+// GetMergeRequest mocks base method
+func (m *MockClientInterface) GetMergeRequest(project string, id int) (*gitlab.MergeRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMergeRequest", project, id)
+	ret0, _ := ret[0].(*gitlab.MergeRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMergeRequest indicates an expected call of GetMergeRequest
+func (mr *MockClientInterfaceMockRecorder) GetMergeRequest(project, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMergeRequest", reflect.TypeOf((*MockClientInterface)(nil).GetMergeRequest), project, id)
+}
+
+// ListMergeRequestDiffs mocks base method
+func (m *MockClientInterface) ListMergeRequestDiffs(project string, id int) ([]*gitlab.Diff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMergeRequestDiffs", project, id)
+	ret0, _ := ret[0].([]*gitlab.Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMergeRequestDiffs indicates an expected call of ListMergeRequestDiffs
+func (mr *MockClientInterfaceMockRecorder) ListMergeRequestDiffs(project, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMergeRequestDiffs", reflect.TypeOf((*MockClientInterface)(nil).ListMergeRequestDiffs), project, id)
+}
+
+// GetMergeRequestApprovals mocks base method
+func (m *MockClientInterface) GetMergeRequestApprovals(project string, id int) (*gitlab.MergeRequestApprovals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMergeRequestApprovals", project, id)
+	ret0, _ := ret[0].(*gitlab.MergeRequestApprovals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMergeRequestApprovals indicates an expected call of GetMergeRequestApprovals
+func (mr *MockClientInterfaceMockRecorder) GetMergeRequestApprovals(project, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMergeRequestApprovals", reflect.TypeOf((*MockClientInterface)(nil).GetMergeRequestApprovals), project, id)
+}
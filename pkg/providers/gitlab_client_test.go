@@ -1,11 +1,27 @@
 package providers
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
+	filet "github.com/Flaque/filet"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/replay"
 	"github.com/xanzy/go-gitlab"
 )
 
@@ -18,8 +34,8 @@ func TestNewClientSucessful(t *testing.T) {
 		BaseURL: "example_url",
 		Api:     MockGitlabClient,
 	}
-	MockGitlabClient.EXPECT().NewClient(client.Token, client.BaseURL).Times(1)
-	client.Api.NewClient(client.Token, client.BaseURL)
+	MockGitlabClient.EXPECT().NewClient(client.Token, client.BaseURL, GitlabPrivateToken, nil).Return(nil).Times(1)
+	assert.Nil(t, client.Api.NewClient(client.Token, client.BaseURL, GitlabPrivateToken, nil))
 }
 
 func TestInitSucessful(t *testing.T) {
@@ -31,16 +47,24 @@ func TestInitSucessful(t *testing.T) {
 		BaseURL: "BaseURL",
 		Api:     MockGitlabClient,
 	}
-	MockGitlabClient.EXPECT().NewClient(client.Token, client.BaseURL).Times(1)
+	MockGitlabClient.EXPECT().NewClient(client.Token, client.BaseURL, GitlabPrivateToken, nil).Return(nil).Times(1)
 	assert.Equal(t, nil, client.Init())
 }
+// unsetGitlabCI clears the GitLab CI variables used as default token and URL
+func unsetGitlabCI(t *testing.T) {
+	t.Setenv("CI_SERVER_URL", "")
+	t.Setenv("CI_JOB_TOKEN", "")
+}
+
 func TestInitMissingToken(t *testing.T) {
+	unsetGitlabCI(t)
 	client := &Gitlab{
 		BaseURL: "BaseURL",
 	}
 	assert.Error(t, client.Init(), "Token can't be empty")
 }
 func TestEmptyBaseURL(t *testing.T) {
+	unsetGitlabCI(t)
 	client := &Gitlab{
 		Token: "token",
 	}
@@ -279,3 +303,284 @@ func TestSearchGroupMultipleGroupsNotFound(t *testing.T) {
 	assert.Equal(t, nil, err)
 	assert.Equal(t, false, valid)
 }
+
+// newGitlabServer answers the users endpoint, recording the authentication headers
+func newGitlabServer(headers http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, name := range []string{"Private-Token", "Job-Token", "Authorization"} {
+			if value := r.Header.Get(name); value != "" {
+				headers.Set(name, value)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"username": "user1"}]`)
+	})
+}
+
+func TestGitlabTokenTypes(t *testing.T) {
+	tests := []TestCase{
+		{Name: "private token", Sample: GitlabPrivateToken, Expected: []string{"Private-Token", "xyz"}},
+		{Name: "default token type", Sample: "", Expected: []string{"Private-Token", "xyz"}},
+		{Name: "job token", Sample: GitlabJobToken, Expected: []string{"Job-Token", "xyz"}},
+		{Name: "oauth token", Sample: GitlabOAuthToken, Expected: []string{"Authorization", "Bearer xyz"}},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		headers := http.Header{}
+		server := httptest.NewServer(newGitlabServer(headers))
+		client := &Gitlab{Token: "xyz", BaseURL: server.URL + "/api/v4", TokenType: test.Sample.(string)}
+		assert.Nil(t, client.Init())
+		valid, err := client.UserExists("user1")
+		assert.Nil(t, err)
+		assert.Equal(t, true, valid)
+		expected := test.Expected.([]string)
+		assert.Equal(t, http.Header{expected[0]: {expected[1]}}, headers)
+		server.Close()
+	}
+	assert.Error(t, (&Gitlab{Token: "xyz", TokenType: "non-existent"}).Init())
+}
+
+func TestGitlabCIDetection(t *testing.T) {
+	t.Setenv("CI_JOB_TOKEN", "job-token")
+	t.Setenv("CI_SERVER_URL", "https://gitlab.example.org/")
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockGitlabClient := NewMockClientInterface(mockCtrl)
+	MockGitlabClient.EXPECT().NewClient("job-token", "https://gitlab.example.org/api/v4", GitlabJobToken, nil).Return(nil).Times(1)
+	client := &Gitlab{Api: MockGitlabClient}
+	assert.Nil(t, client.Init())
+
+	MockGitlabClient.EXPECT().NewClient("xyz", "https://gitlab.com/api/v4", GitlabPrivateToken, nil).Return(nil).Times(1)
+	client = &Gitlab{Token: "xyz", BaseURL: "https://gitlab.com/api/v4", Api: MockGitlabClient}
+	assert.Nil(t, client.Init())
+
+	t.Log("an explicit token type isn't replaced by the job token")
+	client = &Gitlab{TokenType: GitlabOAuthToken, Api: MockGitlabClient}
+	assert.Error(t, client.Init(), "Token can't be empty")
+}
+
+func TestGitlabNewClientError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockGitlabClient := NewMockClientInterface(mockCtrl)
+	MockGitlabClient.EXPECT().NewClient("xyz", "://invalid", GitlabPrivateToken, nil).Return(fmt.Errorf("Couldn't create Gitlab client")).Times(1)
+	client := &Gitlab{Token: "xyz", BaseURL: "://invalid", Api: MockGitlabClient}
+	assert.Equal(t, fmt.Errorf("Couldn't create Gitlab client"), client.Init())
+	assert.Error(t, (&GitlabClient{}).NewClient("xyz", "://invalid", GitlabPrivateToken, nil))
+}
+
+// writeClientCertificate creates a self-signed client certificate, returning
+// its certificate and key files
+func writeClientCertificate(t *testing.T, dir string) (*x509.Certificate, string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "codeowners-verifier"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	filet.File(t, certFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})))
+	filet.File(t, keyFile, string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})))
+	return cert, certFile, keyFile
+}
+
+func TestGitlabTLS(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	clientCert, certFile, keyFile := writeClientCertificate(t, dir)
+	server := httptest.NewUnstartedServer(newGitlabServer(http.Header{}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.RequireAndVerifyClientCert}
+	server.Config.ErrorLog = stdlog.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	caFile := filepath.Join(dir, "ca.pem")
+	filet.File(t, caFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+
+	tests := []TestCase{
+		{
+			Name:     "CA bundle and client certificate",
+			Sample:   &Gitlab{CAFile: caFile, CertFile: certFile, KeyFile: keyFile},
+			Expected: ReturnWithError{Value: true},
+		},
+		{
+			Name:     "missing client certificate",
+			Sample:   &Gitlab{CAFile: caFile},
+			Expected: ReturnWithError{Error: true},
+		},
+		{
+			Name:     "unknown CA",
+			Sample:   &Gitlab{CertFile: certFile, KeyFile: keyFile},
+			Expected: ReturnWithError{Error: true},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		client := test.Sample.(*Gitlab)
+		client.Token = "xyz"
+		client.BaseURL = server.URL + "/api/v4"
+		assert.Nil(t, client.Init())
+		valid, err := client.UserExists("user1")
+		expected := test.Expected.(ReturnWithError)
+		if expected.Error {
+			assert.Error(t, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Value, valid)
+	}
+
+	filet.File(t, filepath.Join(dir, "empty.pem"), "")
+	assert.Error(t, (&Gitlab{Token: "xyz", CAFile: filepath.Join(dir, "empty.pem")}).Init())
+	assert.Error(t, (&Gitlab{Token: "xyz", CAFile: filepath.Join(dir, "non-existent.pem")}).Init())
+	assert.Error(t, (&Gitlab{Token: "xyz", CertFile: certFile}).Init())
+}
+
+func TestGitlabProxy(t *testing.T) {
+	var requests []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		assert.Equal(t, "gitlab.example.org", r.URL.Host)
+		newGitlabServer(http.Header{}).ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	client := &Gitlab{Token: "xyz", BaseURL: "http://gitlab.example.org/api/v4", ProxyURL: proxy.URL}
+	assert.Nil(t, client.Init())
+	valid, err := client.UserExists("user1")
+	assert.Nil(t, err)
+	assert.Equal(t, true, valid)
+	assert.Contains(t, requests, "/api/v4/users")
+	assert.Error(t, (&Gitlab{Token: "xyz", ProxyURL: "://invalid"}).Init())
+}
+
+func TestGitlabClientReplay(t *testing.T) {
+	recorder, err := replay.New(replay.ModeReplay, filepath.Join("testdata", "gitlab.json"))
+	assert.Nil(t, err)
+	WrapTransport = recorder.Wrap
+	defer func() { WrapTransport = nil }()
+	client := &Gitlab{Token: "xyz", BaseURL: "https://gitlab.example.org/api/v4"}
+	assert.Nil(t, client.Init())
+	tests := []TestCase{
+		{Name: "user on the second page", Sample: []string{"user", "user"}, Expected: ReturnWithError{Value: true}},
+		{Name: "group on the second page", Sample: []string{"group", "developers"}, Expected: ReturnWithError{Value: true}},
+		{Name: "API error", Sample: []string{"group", "qa"}, Expected: ReturnWithError{Error: true}},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.([]string)
+		expected := test.Expected.(ReturnWithError)
+		var valid bool
+		if sample[0] == "user" {
+			valid, err = client.UserExists(sample[1])
+		} else {
+			valid, err = client.GroupExists(sample[1])
+		}
+		if expected.Error {
+			assert.Error(t, err)
+			continue
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, expected.Value, valid)
+	}
+	users, err := client.Api.ListUsers("user")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(users), "every page should be listed")
+}
+
+func TestGitlabIsMember(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockGitlabClient := NewMockClientInterface(mockCtrl)
+	client := &Gitlab{Token: "xyz", Api: MockGitlabClient}
+	MockGitlabClient.EXPECT().ListGroupMembers("org/developers", "user1").Return([]*gitlab.GroupMember{{Username: "user10"}, {Username: "user1"}}, nil).Times(1)
+	MockGitlabClient.EXPECT().ListGroupMembers("org/developers", "user2").Return([]*gitlab.GroupMember{{Username: "user20"}}, nil).Times(1)
+	MockGitlabClient.EXPECT().ListGroupMembers("org/qa", "user1").Return(nil, fmt.Errorf("Error listing members of group org/qa")).Times(1)
+	member, err := client.IsMember("user1", "org/developers")
+	assert.Nil(t, err)
+	assert.Equal(t, true, member)
+	member, err = client.IsMember("user2", "org/developers")
+	assert.Nil(t, err)
+	assert.Equal(t, false, member)
+	_, err = client.IsMember("user1", "org/qa")
+	assert.Error(t, err)
+}
+
+func TestGitlabGetMergeRequest(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	MockGitlabClient := NewMockClientInterface(mockCtrl)
+	client := &Gitlab{Token: "xyz", Api: MockGitlabClient}
+	MockGitlabClient.EXPECT().GetMergeRequest("group/project", 42).Return(&gitlab.MergeRequest{Author: &gitlab.BasicUser{Username: "author"}}, nil).Times(1)
+	MockGitlabClient.EXPECT().ListMergeRequestDiffs("group/project", 42).Return([]*gitlab.Diff{
+		{OldPath: "README.md", NewPath: "README.md"},
+		{OldPath: "old/main.go", NewPath: "new/main.go", RenamedFile: true},
+	}, nil).Times(1)
+	MockGitlabClient.EXPECT().GetMergeRequestApprovals("group/project", 42).Return(&gitlab.MergeRequestApprovals{
+		ApprovedBy: []*gitlab.MergeRequestApproverUser{{User: &gitlab.BasicUser{Username: "user1"}}},
+	}, nil).Times(1)
+	mr, err := client.GetMergeRequest("group/project", 42)
+	assert.Nil(t, err)
+	assert.Equal(t, &MergeRequest{
+		ID:        42,
+		Author:    "author",
+		Files:     []string{"README.md", "new/main.go", "old/main.go"},
+		Approvers: []string{"user1"},
+	}, mr)
+
+	MockGitlabClient.EXPECT().GetMergeRequest("group/project", 43).Return(&gitlab.MergeRequest{}, nil).Times(1)
+	MockGitlabClient.EXPECT().ListMergeRequestDiffs("group/project", 43).Return(nil, fmt.Errorf("Error getting merge request 43 diffs")).Times(1)
+	_, err = client.GetMergeRequest("group/project", 43)
+	assert.Error(t, err)
+}
+
+func TestGitlabListMergeRequestDiffs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/group/project/merge_requests/42/diffs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"old_path": "README.md", "new_path": "README.md"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"old_path": "main.go", "new_path": "main.go"}]`)
+	})
+	// servers without the diffs API answer the changes API, truncated on large merge requests
+	mux.HandleFunc("/api/v4/projects/group/project/merge_requests/43/changes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"changes": [{"old_path": "README.md", "new_path": "README.md"}]}`)
+	})
+	mux.HandleFunc("/api/v4/projects/group/project/merge_requests/44/changes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"overflow": true, "changes": [{"old_path": "README.md", "new_path": "README.md"}]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	api := &GitlabClient{}
+	assert.Nil(t, api.NewClient("xyz", server.URL+"/api/v4", GitlabPrivateToken, nil))
+	tests := []TestCase{
+		{Name: "every page of the diffs", Sample: 42, Expected: []string{"README.md", "main.go"}},
+		{Name: "changes without the diffs API", Sample: 43, Expected: []string{"README.md"}},
+		{Name: "truncated changes", Sample: 44, Expected: []string(nil)},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		diffs, err := api.ListMergeRequestDiffs("group/project", test.Sample.(int))
+		var files []string
+		for _, diff := range diffs {
+			files = append(files, diff.NewPath)
+		}
+		assert.Equal(t, test.Expected.([]string), files)
+		assert.Equal(t, test.Expected.([]string) == nil, err != nil)
+	}
+}
@@ -0,0 +1,197 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAP represents an LDAP/Active Directory Client configuration.
+// Token is the password used to bind as BindDN, searches are anonymous without BindDN
+type LDAP struct {
+	Token   string
+	BaseURL string
+	BindDN  string
+	// UserBaseDN and GroupBaseDN are where users and groups are searched
+	UserBaseDN  string
+	GroupBaseDN string
+	// UserFilter and GroupFilter restrict the entries considered users and groups.
+	// A filter with %s is a template where the name is placed, otherwise the
+	// name is matched on UserAttribute and GroupAttribute
+	UserFilter  string
+	GroupFilter string
+	// UserAttribute and GroupAttribute hold the name used on the CODEOWNERS file
+	UserAttribute  string
+	GroupAttribute string
+	// MemberAttribute lists the members of a group, either by DN or by name
+	MemberAttribute string
+	conn            *ldap.Conn
+	userTemplate    string
+	groupTemplate   string
+}
+
+// Init initializes the LDAP Client
+func (l *LDAP) Init() error {
+	if l.Token == "" && l.BindDN != "" {
+		return fmt.Errorf("Token can't be empty when binding as %s", l.BindDN)
+	}
+	if l.BaseURL == "" {
+		l.BaseURL = "ldap://localhost:389"
+	}
+	if l.UserFilter == "" {
+		l.UserFilter = "(objectClass=person)"
+	}
+	if l.GroupFilter == "" {
+		l.GroupFilter = "(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=group)(objectClass=posixGroup))"
+	}
+	if l.UserAttribute == "" {
+		l.UserAttribute = "uid"
+	}
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = "cn"
+	}
+	if l.MemberAttribute == "" {
+		l.MemberAttribute = "member"
+	}
+	var err error
+	if l.userTemplate, err = filterTemplate(l.UserFilter, l.UserAttribute); err != nil {
+		return fmt.Errorf("Invalid user filter %s: %s", l.UserFilter, err)
+	}
+	if l.groupTemplate, err = filterTemplate(l.GroupFilter, l.GroupAttribute); err != nil {
+		return fmt.Errorf("Invalid group filter %s: %s", l.GroupFilter, err)
+	}
+	return l.connect()
+}
+
+// filterTemplate returns the filter with a %s for the name, matching it on attribute
+// when the filter isn't a template already
+func filterTemplate(filter string, attribute string) (string, error) {
+	template := filter
+	if !strings.Contains(filter, "%s") {
+		if _, err := ldap.CompileFilter(filter); err != nil {
+			return "", err
+		}
+		template = fmt.Sprintf("(&%s(%s=%%s))", filter, attribute)
+	}
+	if count := strings.Count(template, "%s"); count != 1 {
+		return "", fmt.Errorf("Expected a single %%s, found %d", count)
+	}
+	if _, err := ldap.CompileFilter(strings.Replace(template, "%s", "name", 1)); err != nil {
+		return "", err
+	}
+	return template, nil
+}
+
+// connect opens the connection used by every search, binding as BindDN when it's set
+func (l *LDAP) connect() error {
+	conn, err := ldap.DialURL(l.BaseURL)
+	if err != nil {
+		return fmt.Errorf("Error connecting to %s: %s", l.BaseURL, err)
+	}
+	if l.BindDN != "" {
+		if err := conn.Bind(l.BindDN, l.Token); err != nil {
+			conn.Close()
+			return fmt.Errorf("Error binding as %s: %s", l.BindDN, err)
+		}
+	}
+	l.conn = conn
+	return nil
+}
+
+// Close closes the connection opened by Init
+func (l *LDAP) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+	err := l.conn.Unbind()
+	l.conn = nil
+	return err
+}
+
+// search returns the entries under baseDN matching the filter template filled with name
+func (l *LDAP) search(baseDN string, template string, name string, attributes []string) ([]*ldap.Entry, error) {
+	if l.conn == nil {
+		return nil, fmt.Errorf("Connection to %s is closed", l.BaseURL)
+	}
+	request := ldap.NewSearchRequest(
+		baseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		strings.Replace(template, "%s", ldap.EscapeFilter(name), 1),
+		attributes,
+		nil,
+	)
+	result, err := l.conn.Search(request)
+	if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		// servers drop idle connections, the search is retried once on a new one
+		l.conn.Close()
+		l.conn = nil
+		if err := l.connect(); err != nil {
+			return nil, err
+		}
+		result, err = l.conn.Search(request)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// findUser returns the entry of the user
+func (l *LDAP) findUser(name string) (*ldap.Entry, error) {
+	entries, err := l.search(l.UserBaseDN, l.userTemplate, name, []string{l.UserAttribute})
+	if err != nil {
+		return nil, fmt.Errorf("Error searching for user %s: %s", name, err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries[0], nil
+}
+
+// findGroup returns the entry of the group
+func (l *LDAP) findGroup(name string) (*ldap.Entry, error) {
+	entries, err := l.search(l.GroupBaseDN, l.groupTemplate, name, []string{l.GroupAttribute, l.MemberAttribute})
+	if err != nil {
+		return nil, fmt.Errorf("Error searching for group %s: %s", name, err)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries[0], nil
+}
+
+// UserExists searches an user by name
+func (l *LDAP) UserExists(name string) (bool, error) {
+	user, err := l.findUser(name)
+	return user != nil, err
+}
+
+// GroupExists searches a group by name
+func (l *LDAP) GroupExists(name string) (bool, error) {
+	group, err := l.findGroup(name)
+	return group != nil, err
+}
+
+// IsMember checks if the user is a direct member of the group
+func (l *LDAP) IsMember(username string, group string) (bool, error) {
+	groupEntry, err := l.findGroup(group)
+	if err != nil || groupEntry == nil {
+		return false, err
+	}
+	userEntry, err := l.findUser(username)
+	if err != nil || userEntry == nil {
+		return false, err
+	}
+	for _, member := range groupEntry.GetAttributeValues(l.MemberAttribute) {
+		// posixGroup lists members by name, the other group classes by DN
+		if strings.EqualFold(member, userEntry.DN) || member == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
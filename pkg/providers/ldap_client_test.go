@@ -0,0 +1,355 @@
+package providers
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/stretchr/testify/assert"
+)
+
+// LDAP protocol operations used by the stand-in server
+const (
+	ldapBindRequest      = 0
+	ldapBindResponse     = 1
+	ldapUnbindRequest    = 2
+	ldapSearchRequest    = 3
+	ldapSearchResultItem = 4
+	ldapSearchResultDone = 5
+)
+
+// ldapServer is an in-process LDAP stand-in answering binds and searches
+// against a fixed set of entries
+type ldapServer struct {
+	listener net.Listener
+	bindDN   string
+	password string
+	// anonymous allows searching without binding first
+	anonymous bool
+	entries   map[string]map[string][]string
+	// connections counts the connections accepted
+	connections int32
+}
+
+func newLDAPServer(t *testing.T) *ldapServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := &ldapServer{
+		listener: listener,
+		bindDN:   "cn=admin,dc=example,dc=org",
+		password: "secret",
+		entries: map[string]map[string][]string{
+			"uid=jdoe,ou=people,dc=example,dc=org": {
+				"objectClass": {"person"},
+				"uid":         {"jdoe"},
+			},
+			"uid=jsmith,ou=people,dc=example,dc=org": {
+				"objectClass": {"person"},
+				"uid":         {"jsmith"},
+			},
+			"cn=developers,ou=groups,dc=example,dc=org": {
+				"objectClass": {"groupOfNames"},
+				"cn":          {"developers"},
+				"member":      {"uid=jdoe,ou=people,dc=example,dc=org"},
+			},
+			"cn=ops,ou=groups,dc=example,dc=org": {
+				"objectClass": {"posixGroup"},
+				"cn":          {"ops"},
+				"memberUid":   {"jsmith"},
+			},
+		},
+	}
+	go s.serve()
+	return s
+}
+
+func (s *ldapServer) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+func (s *ldapServer) Close() {
+	s.listener.Close()
+}
+
+func (s *ldapServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		atomic.AddInt32(&s.connections, 1)
+		go s.handle(conn)
+	}
+}
+
+func (s *ldapServer) handle(conn net.Conn) {
+	defer conn.Close()
+	bound := false
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		messageID := request.Children[0].Value.(int64)
+		op := request.Children[1]
+		switch op.Tag {
+		case ldapBindRequest:
+			resultCode := int64(0)
+			if op.Children[1].Data.String() != s.bindDN || op.Children[2].Data.String() != s.password {
+				// invalidCredentials
+				resultCode = 49
+			}
+			bound = resultCode == 0
+			conn.Write(ldapResult(messageID, ldapBindResponse, resultCode).Bytes())
+		case ldapSearchRequest:
+			if !bound && !s.anonymous {
+				// insufficientAccessRights
+				conn.Write(ldapResult(messageID, ldapSearchResultDone, 50).Bytes())
+				continue
+			}
+			baseDN := strings.ToLower(op.Children[0].Data.String())
+			for dn, attributes := range s.entries {
+				if !strings.HasSuffix(dn, baseDN) || !matchesFilter(op.Children[6], attributes) {
+					continue
+				}
+				conn.Write(ldapEntry(messageID, dn, attributes).Bytes())
+			}
+			conn.Write(ldapResult(messageID, ldapSearchResultDone, 0).Bytes())
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+// matchesFilter evaluates the and, or, equality and present filters
+func matchesFilter(filter *ber.Packet, attributes map[string][]string) bool {
+	switch filter.Tag {
+	case 0:
+		for _, child := range filter.Children {
+			if !matchesFilter(child, attributes) {
+				return false
+			}
+		}
+		return true
+	case 1:
+		for _, child := range filter.Children {
+			if matchesFilter(child, attributes) {
+				return true
+			}
+		}
+		return false
+	case 3:
+		name, value := filter.Children[0].Data.String(), filter.Children[1].Data.String()
+		for _, v := range attributes[name] {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	case 7:
+		_, ok := attributes[filter.Data.String()]
+		return ok
+	}
+	return false
+}
+
+func ldapMessage(messageID int64, op *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	packet.AppendChild(op)
+	return packet
+}
+
+func ldapResult(messageID int64, tag ber.Tag, resultCode int64) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	return ldapMessage(messageID, op)
+}
+
+func ldapEntry(messageID int64, dn string, attributes map[string][]string) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapSearchResultItem, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attribute.AppendChild(set)
+		list.AppendChild(attribute)
+	}
+	op.AppendChild(list)
+	return ldapMessage(messageID, op)
+}
+
+func newTestLDAP(t *testing.T, server *ldapServer) *LDAP {
+	client := &LDAP{
+		Token:       "secret",
+		BaseURL:     server.URL(),
+		BindDN:      "cn=admin,dc=example,dc=org",
+		UserBaseDN:  "ou=people,dc=example,dc=org",
+		GroupBaseDN: "ou=groups,dc=example,dc=org",
+	}
+	assert.Nil(t, client.Init())
+	return client
+}
+
+func TestLDAPInit(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := &LDAP{Token: "token", BaseURL: server.URL()}
+	assert.Equal(t, nil, client.Init())
+	defer client.Close()
+	assert.Equal(t, "uid", client.UserAttribute)
+	assert.Equal(t, "member", client.MemberAttribute)
+	client = &LDAP{}
+	client.Init()
+	assert.Equal(t, "ldap://localhost:389", client.BaseURL)
+	assert.Equal(t, fmt.Errorf("Token can't be empty when binding as cn=admin,dc=example,dc=org"), (&LDAP{BindDN: "cn=admin,dc=example,dc=org"}).Init())
+}
+
+func TestLDAPFilterTemplates(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	tests := []TestCase{
+		{Name: "filter combined with the attribute", Sample: "(objectClass=person)", Expected: true},
+		{Name: "template", Sample: "(&(objectClass=person)(|(uid=%s)(cn=%s)))", Expected: false},
+		{Name: "invalid filter", Sample: "(objectClass=person", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		client := &LDAP{BaseURL: server.URL(), UserFilter: test.Sample.(string)}
+		err := client.Init()
+		client.Close()
+		assert.Equal(t, test.Expected.(bool), err == nil)
+	}
+	server.anonymous = true
+	client := &LDAP{BaseURL: server.URL(), UserFilter: "(&(objectClass=person)(uid=%s))"}
+	assert.Nil(t, client.Init())
+	defer client.Close()
+	valid, err := client.UserExists("jdoe")
+	assert.Nil(t, err)
+	assert.Equal(t, true, valid)
+	valid, err = client.UserExists("*")
+	assert.Nil(t, err)
+	assert.Equal(t, false, valid)
+}
+
+func TestLDAPReusesConnection(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := newTestLDAP(t, server)
+	for _, name := range []string{"jdoe", "jsmith", "jane"} {
+		_, err := client.IsMember(name, "developers")
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.connections))
+	assert.Nil(t, client.Close())
+	_, err := client.UserExists("jdoe")
+	assert.Error(t, err)
+	assert.Nil(t, client.Close())
+}
+
+func TestLDAPUserExists(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := newTestLDAP(t, server)
+	defer client.Close()
+	tests := []TestCase{
+		{Name: "existing user", Sample: "jdoe", Expected: true},
+		{Name: "non-existent user", Sample: "jane", Expected: false},
+		{Name: "group isn't an user", Sample: "developers", Expected: false},
+		{Name: "filter characters are escaped", Sample: "*", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.UserExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+}
+
+func TestLDAPGroupExists(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := newTestLDAP(t, server)
+	defer client.Close()
+	tests := []TestCase{
+		{Name: "existing groupOfNames", Sample: "developers", Expected: true},
+		{Name: "existing posixGroup", Sample: "ops", Expected: true},
+		{Name: "non-existent group", Sample: "qa", Expected: false},
+		{Name: "user isn't a group", Sample: "jdoe", Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		valid, err := client.GroupExists(test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+}
+
+func TestLDAPIsMember(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := newTestLDAP(t, server)
+	defer client.Close()
+	tests := []TestCase{
+		{Name: "member by DN", Sample: []string{"jdoe", "developers", "member"}, Expected: true},
+		{Name: "not a member", Sample: []string{"jsmith", "developers", "member"}, Expected: false},
+		{Name: "member by name", Sample: []string{"jsmith", "ops", "memberUid"}, Expected: true},
+		{Name: "non-existent group", Sample: []string{"jdoe", "qa", "member"}, Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.([]string)
+		client.MemberAttribute = sample[2]
+		member, err := client.IsMember(sample[0], sample[1])
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), member)
+	}
+}
+
+func TestLDAPInvalidCredentials(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := &LDAP{Token: "wrong", BaseURL: server.URL(), BindDN: "cn=admin,dc=example,dc=org"}
+	assert.Error(t, client.Init())
+}
+
+func TestLDAPAnonymousBind(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	client := &LDAP{BaseURL: server.URL(), UserBaseDN: "ou=people,dc=example,dc=org"}
+	assert.Nil(t, client.Init())
+	defer client.Close()
+	_, err := client.UserExists("jdoe")
+	assert.Error(t, err)
+	server.anonymous = true
+	valid, err := client.UserExists("jdoe")
+	assert.Nil(t, err)
+	assert.Equal(t, true, valid)
+}
+
+func TestInitLDAPProviderWithSettings(t *testing.T) {
+	server := newLDAPServer(t)
+	defer server.Close()
+	provider, err := InitProviderWithSettings("ldap", "secret", server.URL(), Settings{
+		"user-base-dn":   "ou=people,dc=example,dc=org",
+		"user-attribute": "sAMAccountName",
+	})
+	assert.Nil(t, err)
+	client := provider.(*LDAP)
+	defer client.Close()
+	assert.Equal(t, "ou=people,dc=example,dc=org", client.UserBaseDN)
+	assert.Equal(t, "sAMAccountName", client.UserAttribute)
+	assert.Equal(t, "cn", client.GroupAttribute)
+	_, ok := provider.(MembershipChecker)
+	assert.True(t, ok)
+}
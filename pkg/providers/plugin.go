@@ -0,0 +1,305 @@
+package providers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginPrefix is the prefix of the executables discovered on PATH as providers,
+// e.g. codeowners-verifier-provider-okta is the okta provider
+const PluginPrefix = "codeowners-verifier-provider-"
+
+// PluginTimeout is how long a plugin has to answer a request, unless Timeout is set
+const PluginTimeout = 30 * time.Second
+
+// Methods of the plugin protocol
+const (
+	PluginInit        = "init"
+	PluginUserExists  = "user_exists"
+	PluginGroupExists = "group_exists"
+	PluginResolve     = "resolve"
+)
+
+// PluginUnsupported is answered as the error of the methods a plugin doesn't implement,
+// resolve requests are then sent as user_exists and group_exists requests for every name
+const PluginUnsupported = "unsupported"
+
+// errPluginUnsupported is returned by call when the plugin doesn't implement the method
+var errPluginUnsupported = errors.New(PluginUnsupported)
+
+// PluginRequest is sent by the verifier to the plugin as a single JSON line on stdin
+type PluginRequest struct {
+	ID       int      `json:"id"`
+	Method   string   `json:"method"`
+	Token    string   `json:"token,omitempty"`
+	BaseURL  string   `json:"base_url,omitempty"`
+	Settings Settings `json:"settings,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Names    []string `json:"names,omitempty"`
+}
+
+// PluginResponse is answered by the plugin as a single JSON line on stdout
+type PluginResponse struct {
+	ID      int                  `json:"id"`
+	Exists  bool                 `json:"exists"`
+	Results map[string]OwnerInfo `json:"results,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// Plugin represents a provider implemented by an external executable
+type Plugin struct {
+	Name     string
+	Path     string
+	Token    string
+	BaseURL  string
+	Settings Settings
+	Timeout  time.Duration
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	pipe     *os.File
+	stdout   *bufio.Reader
+	lastID   int
+	mu       sync.Mutex
+	// noBatch is set when the plugin doesn't implement resolve
+	noBatch bool
+}
+
+// discoverPlugins returns the plugins found on PATH by provider name,
+// the first executable found wins like the shell does
+func discoverPlugins() map[string]string {
+	plugins := make(map[string]string)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimPrefix(entry.Name(), PluginPrefix)
+			if name == entry.Name() || name == "" {
+				continue
+			}
+			if _, ok := plugins[name]; ok {
+				continue
+			}
+			info, err := os.Stat(filepath.Join(dir, entry.Name()))
+			if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+			plugins[name] = filepath.Join(dir, entry.Name())
+		}
+	}
+	return plugins
+}
+
+// listPlugins returns the names of the plugins found on PATH
+func listPlugins() []string {
+	var names []string
+	for name := range discoverPlugins() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Init starts the plugin executable and sends it the configuration
+func (p *Plugin) Init() error {
+	if p.Path == "" {
+		return fmt.Errorf("Plugin %s not found on PATH", p.Name)
+	}
+	p.cmd = exec.Command(p.Path)
+	p.cmd.Stderr = os.Stderr
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	p.stdin = stdin
+	// the pipe is an *os.File, so reads can have a deadline
+	p.pipe, _ = stdout.(*os.File)
+	p.stdout = bufio.NewReader(stdout)
+	if err := p.cmd.Start(); err != nil {
+		return fmt.Errorf("Couldn't start plugin %s: %s", p.Name, err)
+	}
+	_, err = p.call(PluginRequest{
+		Method:   PluginInit,
+		Token:    p.Token,
+		BaseURL:  p.BaseURL,
+		Settings: p.Settings,
+	})
+	if err != nil {
+		p.Close()
+		return err
+	}
+	return nil
+}
+
+// Close stops the plugin executable
+func (p *Plugin) Close() error {
+	if p.stdin != nil {
+		p.stdin.Close()
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		return p.cmd.Wait()
+	}
+	return nil
+}
+
+// call sends a request to the plugin and waits for its response
+func (p *Plugin) call(request PluginRequest) (*PluginResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastID++
+	request.ID = p.lastID
+	content, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.stdin.Write(append(content, '\n')); err != nil {
+		return nil, fmt.Errorf("Error calling plugin %s: %s", p.Name, err)
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = PluginTimeout
+	}
+	if p.pipe != nil {
+		if err := p.pipe.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, fmt.Errorf("Error calling plugin %s: %s", p.Name, err)
+		}
+	}
+	line, err := p.stdout.ReadBytes('\n')
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		// a late answer would be read as the response of the next request
+		p.cmd.Process.Kill()
+		return nil, fmt.Errorf("Plugin %s didn't answer in %s", p.Name, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading plugin %s response: %s", p.Name, err)
+	}
+	response := &PluginResponse{}
+	if err := json.Unmarshal(line, response); err != nil {
+		return nil, fmt.Errorf("Invalid plugin %s response: %s", p.Name, err)
+	}
+	if response.ID != request.ID {
+		return nil, fmt.Errorf("Invalid plugin %s response: expected id %d, got %d", p.Name, request.ID, response.ID)
+	}
+	if response.Error == PluginUnsupported {
+		return nil, errPluginUnsupported
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("%s", response.Error)
+	}
+	return response, nil
+}
+
+// UserExists asks the plugin if the user exists
+func (p *Plugin) UserExists(name string) (bool, error) {
+	response, err := p.call(PluginRequest{Method: PluginUserExists, Name: name})
+	if err != nil {
+		return false, err
+	}
+	return response.Exists, nil
+}
+
+// GroupExists asks the plugin if the group exists
+func (p *Plugin) GroupExists(name string) (bool, error) {
+	response, err := p.call(PluginRequest{Method: PluginGroupExists, Name: name})
+	if err != nil {
+		return false, err
+	}
+	return response.Exists, nil
+}
+
+// ResolveOwners asks the plugin about many names in a single call, or about
+// each name when the plugin doesn't implement resolve
+func (p *Plugin) ResolveOwners(names []string) (map[string]OwnerInfo, error) {
+	if p.noBatch {
+		return resolveEach(p, names)
+	}
+	response, err := p.call(PluginRequest{Method: PluginResolve, Names: names})
+	if err == errPluginUnsupported {
+		p.noBatch = true
+		return resolveEach(p, names)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return response.Results, nil
+}
+
+// resolveAll answers the resolve method, telling both if each name is an user and a group
+// unless the provider has its own batch API
+func resolveAll(provider Provider, names []string) (map[string]OwnerInfo, error) {
+	if resolver, ok := provider.(BatchResolver); ok {
+		return resolver.ResolveOwners(names)
+	}
+	return resolveEach(provider, names)
+}
+
+// resolveEach tells if each name is an user and a group asking the provider about every name
+func resolveEach(provider Provider, names []string) (map[string]OwnerInfo, error) {
+	owners := make(map[string]OwnerInfo)
+	for _, name := range names {
+		user, err := provider.UserExists(name)
+		if err != nil {
+			return nil, err
+		}
+		group, err := provider.GroupExists(name)
+		if err != nil {
+			return nil, err
+		}
+		owners[name] = OwnerInfo{User: user, Group: group}
+	}
+	return owners, nil
+}
+
+// ServePlugin implements the plugin protocol for a Provider, reading requests from in
+// and writing responses to out until in is closed. Plugins written in Go can call it
+// with os.Stdin and os.Stdout; init requests are answered by initialize
+func ServePlugin(initialize func(request PluginRequest) (Provider, error), in io.Reader, out io.Writer) error {
+	var provider Provider
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	encoder := json.NewEncoder(out)
+	for scanner.Scan() {
+		request := PluginRequest{}
+		if err := json.Unmarshal(scanner.Bytes(), &request); err != nil {
+			return fmt.Errorf("Invalid request: %s", err)
+		}
+		response := PluginResponse{ID: request.ID}
+		var err error
+		switch {
+		case request.Method == PluginInit:
+			provider, err = initialize(request)
+		case provider == nil:
+			err = fmt.Errorf("plugin not initialized")
+		case request.Method == PluginUserExists:
+			response.Exists, err = provider.UserExists(request.Name)
+		case request.Method == PluginGroupExists:
+			response.Exists, err = provider.GroupExists(request.Name)
+		case request.Method == PluginResolve:
+			response.Results, err = resolveAll(provider, request.Names)
+		default:
+			err = fmt.Errorf("unknown method %s", request.Method)
+		}
+		if err != nil {
+			response = PluginResponse{ID: request.ID, Error: err.Error()}
+		}
+		if err := encoder.Encode(response); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
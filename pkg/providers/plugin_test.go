@@ -0,0 +1,171 @@
+package providers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPluginEnv makes the test binary act as a plugin, so tests can exec it
+const testPluginEnv = "CODEOWNERS_VERIFIER_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		err := ServePlugin(func(request PluginRequest) (Provider, error) {
+			if request.Token == "" {
+				return nil, fmt.Errorf("Token can't be empty")
+			}
+			static := &staticProvider{
+				users:  []string{"user1", request.Settings["extra-user"]},
+				groups: []string{"group1"},
+			}
+			if request.Settings["resolve"] == PluginUnsupported {
+				return &unbatchedProvider{static}, nil
+			}
+			return static, nil
+		}, os.Stdin, os.Stdout)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// unbatchedProvider answers resolve requests as unsupported
+type unbatchedProvider struct {
+	*staticProvider
+}
+
+func (u *unbatchedProvider) ResolveOwners(names []string) (map[string]OwnerInfo, error) {
+	return nil, fmt.Errorf(PluginUnsupported)
+}
+
+// installTestPlugin puts a plugin named static on PATH, running the test binary
+func installTestPlugin(t *testing.T) string {
+	dir := filet.TmpDir(t, "")
+	path := filepath.Join(dir, PluginPrefix+"static")
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 exec %q -test.run=^$ \"$@\"\n", testPluginEnv, os.Args[0])
+	assert.Nil(t, os.WriteFile(path, []byte(script), 0755))
+	t.Setenv("PATH", dir)
+	return path
+}
+
+func TestListPlugins(t *testing.T) {
+	defer filet.CleanUp(t)
+	installTestPlugin(t)
+	dir := filepath.SplitList(os.Getenv("PATH"))[0]
+	filet.File(t, filepath.Join(dir, PluginPrefix+"not-executable"), "")
+	assert.Equal(t, []string{"static"}, listPlugins())
+	assert.Contains(t, ListProviders(), "static")
+}
+
+func TestPlugin(t *testing.T) {
+	defer filet.CleanUp(t)
+	installTestPlugin(t)
+	provider, err := InitProviderWithSettings("static", "xyz", "", Settings{"extra-user": "user2"})
+	assert.Nil(t, err)
+	plugin := provider.(*Plugin)
+	defer plugin.Close()
+	tests := []TestCase{
+		{Name: "existing user", Sample: []string{"user", "user1"}, Expected: true},
+		{Name: "user from settings", Sample: []string{"user", "user2"}, Expected: true},
+		{Name: "non-existent user", Sample: []string{"user", "user3"}, Expected: false},
+		{Name: "existing group", Sample: []string{"group", "group1"}, Expected: true},
+		{Name: "non-existent group", Sample: []string{"group", "group2"}, Expected: false},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.([]string)
+		var valid bool
+		if sample[0] == "user" {
+			valid, err = plugin.UserExists(sample[1])
+		} else {
+			valid, err = plugin.GroupExists(sample[1])
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(bool), valid)
+	}
+	results, err := plugin.ResolveOwners([]string{"user1", "group1", "other"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]OwnerInfo{
+		"user1":  {User: true},
+		"group1": {Group: true},
+		"other":  {},
+	}, results)
+}
+
+func TestPluginWithoutResolve(t *testing.T) {
+	defer filet.CleanUp(t)
+	installTestPlugin(t)
+	provider, err := InitProviderWithSettings("static", "xyz", "", Settings{"resolve": PluginUnsupported})
+	assert.Nil(t, err)
+	plugin := provider.(*Plugin)
+	defer plugin.Close()
+	for i := 0; i < 2; i++ {
+		results, err := plugin.ResolveOwners([]string{"user1", "group1", "other"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]OwnerInfo{
+			"user1":  {User: true},
+			"group1": {Group: true},
+			"other":  {},
+		}, results)
+	}
+	assert.Equal(t, true, plugin.noBatch)
+}
+
+func TestPluginErrors(t *testing.T) {
+	defer filet.CleanUp(t)
+	installTestPlugin(t)
+	_, err := InitProvider("static", "", "")
+	assert.Equal(t, fmt.Errorf("Token can't be empty"), err)
+	_, err = InitProvider("non-existent", "xyz", "")
+	assert.Equal(t, fmt.Errorf("Invalid provider"), err)
+	plugin := &Plugin{Name: "static"}
+	assert.Error(t, plugin.Init())
+
+	// a plugin reading the requests without ever answering them
+	dir := filepath.SplitList(os.Getenv("PATH"))[0]
+	silent := filepath.Join(dir, PluginPrefix+"silent")
+	assert.Nil(t, os.WriteFile(silent, []byte("#!/bin/sh\n/bin/cat > /dev/null\n"), 0755))
+	plugin = &Plugin{Name: "silent", Path: silent, Token: "xyz", Timeout: 100 * time.Millisecond}
+	assert.Equal(t, fmt.Errorf("Plugin silent didn't answer in 100ms"), plugin.Init())
+}
+
+func TestServePlugin(t *testing.T) {
+	initialize := func(request PluginRequest) (Provider, error) {
+		return &staticProvider{users: []string{"user1"}}, nil
+	}
+	tests := []TestCase{
+		{
+			Name:     "requests before init",
+			Sample:   `{"id":1,"method":"user_exists","name":"user1"}`,
+			Expected: `{"id":1,"exists":false,"error":"plugin not initialized"}`,
+		},
+		{
+			Name:     "unknown method",
+			Sample:   `{"id":1,"method":"init","token":"xyz"}` + "\n" + `{"id":2,"method":"list"}`,
+			Expected: `{"id":1,"exists":false}` + "\n" + `{"id":2,"exists":false,"error":"unknown method list"}`,
+		},
+		{
+			Name:     "user exists",
+			Sample:   `{"id":1,"method":"init","token":"xyz"}` + "\n" + `{"id":2,"method":"user_exists","name":"user1"}`,
+			Expected: `{"id":1,"exists":false}` + "\n" + `{"id":2,"exists":true}`,
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		out := &strings.Builder{}
+		err := ServePlugin(initialize, strings.NewReader(test.Sample.(string)), out)
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected.(string)+"\n", out.String())
+	}
+	err := ServePlugin(initialize, strings.NewReader("invalid"), &strings.Builder{})
+	assert.Error(t, err)
+}
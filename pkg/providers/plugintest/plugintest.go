@@ -0,0 +1,132 @@
+// Package plugintest checks that a provider plugin follows the protocol expected by
+// codeowners-verifier. Plugin authors can run it from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		plugintest.Run(t, plugintest.Config{
+//			Path:    "./codeowners-verifier-provider-okta",
+//			Token:   os.Getenv("OKTA_TOKEN"),
+//			Users:   []string{"jdoe"},
+//			Groups:  []string{"developers"},
+//			Missing: []string{"non-existent"},
+//		})
+//	}
+package plugintest
+
+import (
+	"bufio"
+	"encoding/json"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+)
+
+// Config describes the plugin under test and names known to exist, or not, on its backend
+type Config struct {
+	Path     string
+	Token    string
+	BaseURL  string
+	Settings providers.Settings
+	Users    []string
+	Groups   []string
+	Missing  []string
+}
+
+// Run checks the plugin answers every method of the protocol as expected
+func Run(t *testing.T, c Config) {
+	plugin := &providers.Plugin{
+		Name:     "conformance",
+		Path:     c.Path,
+		Token:    c.Token,
+		BaseURL:  c.BaseURL,
+		Settings: c.Settings,
+	}
+	if err := plugin.Init(); err != nil {
+		t.Fatalf("plugin failed to initialize: %s", err)
+	}
+	t.Run("user_exists", func(t *testing.T) {
+		for _, name := range c.Users {
+			exists, err := plugin.UserExists(name)
+			assert.Nil(t, err)
+			assert.True(t, exists, "user %s should exist", name)
+		}
+		for _, name := range c.Missing {
+			exists, err := plugin.UserExists(name)
+			assert.Nil(t, err)
+			assert.False(t, exists, "user %s shouldn't exist", name)
+		}
+	})
+	t.Run("group_exists", func(t *testing.T) {
+		for _, name := range c.Groups {
+			exists, err := plugin.GroupExists(name)
+			assert.Nil(t, err)
+			assert.True(t, exists, "group %s should exist", name)
+		}
+		for _, name := range c.Missing {
+			exists, err := plugin.GroupExists(name)
+			assert.Nil(t, err)
+			assert.False(t, exists, "group %s shouldn't exist", name)
+		}
+	})
+	t.Run("resolve", func(t *testing.T) {
+		var names []string
+		names = append(names, c.Users...)
+		names = append(names, c.Groups...)
+		names = append(names, c.Missing...)
+		results, err := plugin.ResolveOwners(names)
+		assert.Nil(t, err)
+		for _, name := range names {
+			user, _ := plugin.UserExists(name)
+			group, _ := plugin.GroupExists(name)
+			assert.Equal(t, providers.OwnerInfo{User: user, Group: group}, results[name], "resolve should agree with single lookups for %s", name)
+		}
+	})
+	t.Run("exits when stdin is closed", func(t *testing.T) {
+		done := make(chan error)
+		go func() { done <- plugin.Close() }()
+		select {
+		case err := <-done:
+			assert.Nil(t, err, "plugin should exit successfully")
+		case <-time.After(10 * time.Second):
+			t.Error("plugin didn't exit after stdin was closed")
+		}
+	})
+	t.Run("protocol errors", func(t *testing.T) {
+		runProtocolErrors(t, c)
+	})
+}
+
+// runProtocolErrors talks to the plugin directly, checking it answers invalid
+// requests with an error and keeps serving
+func runProtocolErrors(t *testing.T, c Config) {
+	cmd := exec.Command(c.Path)
+	stdin, err := cmd.StdinPipe()
+	assert.Nil(t, err)
+	stdout, err := cmd.StdoutPipe()
+	assert.Nil(t, err)
+	assert.Nil(t, cmd.Start())
+	defer cmd.Wait()
+	defer stdin.Close()
+	reader := bufio.NewReader(stdout)
+	call := func(request providers.PluginRequest) providers.PluginResponse {
+		content, _ := json.Marshal(request)
+		_, err := stdin.Write(append(content, '\n'))
+		assert.Nil(t, err)
+		line, err := reader.ReadBytes('\n')
+		assert.Nil(t, err)
+		response := providers.PluginResponse{}
+		assert.Nil(t, json.Unmarshal(line, &response), "response should be a JSON line")
+		assert.Equal(t, request.ID, response.ID, "response should have the request id")
+		return response
+	}
+	response := call(providers.PluginRequest{ID: 1, Method: providers.PluginInit, Token: c.Token, BaseURL: c.BaseURL, Settings: c.Settings})
+	assert.Empty(t, response.Error)
+	response = call(providers.PluginRequest{ID: 2, Method: "non-existent-method"})
+	assert.NotEmpty(t, response.Error, "unknown methods should be answered with an error")
+	if len(c.Missing) > 0 {
+		response = call(providers.PluginRequest{ID: 3, Method: providers.PluginUserExists, Name: c.Missing[0]})
+		assert.Empty(t, response.Error, "plugin should keep serving after an error")
+	}
+}
@@ -0,0 +1,69 @@
+package plugintest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/providers"
+)
+
+// testPluginEnv makes the test binary act as a plugin, so Run can exec it
+const testPluginEnv = "CODEOWNERS_VERIFIER_TEST_PLUGIN"
+
+// staticProvider answers from fixed lists
+type staticProvider struct {
+	users  []string
+	groups []string
+}
+
+func (s *staticProvider) Init() error {
+	return nil
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *staticProvider) UserExists(name string) (bool, error) {
+	return contains(s.users, name), nil
+}
+
+func (s *staticProvider) GroupExists(name string) (bool, error) {
+	return contains(s.groups, name), nil
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) != "" {
+		err := providers.ServePlugin(func(request providers.PluginRequest) (providers.Provider, error) {
+			return &staticProvider{users: []string{"user1"}, groups: []string{"group1"}}, nil
+		}, os.Stdin, os.Stdout)
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestRun(t *testing.T) {
+	defer filet.CleanUp(t)
+	path := filepath.Join(filet.TmpDir(t, ""), providers.PluginPrefix+"static")
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 exec %q -test.run=^$ \"$@\"\n", testPluginEnv, os.Args[0])
+	assert.Nil(t, os.WriteFile(path, []byte(script), 0755))
+	Run(t, Config{
+		Path:    path,
+		Token:   "xyz",
+		Users:   []string{"user1"},
+		Groups:  []string{"group1"},
+		Missing: []string{"user2", "group2"},
+	})
+}
@@ -1,6 +1,11 @@
 package providers
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
 
 type Provider interface {
 	Init() error
@@ -8,23 +13,233 @@ type Provider interface {
 	GroupExists(username string) (bool, error)
 }
 
+// MembershipChecker is implemented by providers able to tell if an user belongs to a group
+type MembershipChecker interface {
+	IsMember(username string, group string) (bool, error)
+}
+
+// MergeRequest represents the changed files and the approvals of a merge request
+type MergeRequest struct {
+	ID     int
+	Author string
+	// Files changed, renamed files are listed by both their old and new paths
+	Files []string
+	// Approvers are the usernames of the users that approved the merge request
+	Approvers []string
+}
+
+// MergeRequestReader is implemented by providers able to read merge requests
+type MergeRequestReader interface {
+	GetMergeRequest(project string, id int) (*MergeRequest, error)
+}
+
+// OwnerInfo tells if a name is an user and/or a group
+type OwnerInfo struct {
+	User  bool `json:"user"`
+	Group bool `json:"group"`
+}
+
+// BatchResolver is implemented by providers able to look up many names in a single request
+type BatchResolver interface {
+	ResolveOwners(names []string) (map[string]OwnerInfo, error)
+}
+
+// Settings holds provider specific configuration, e.g. the base DNs of the ldap provider
+type Settings map[string]string
+
+// WrapTransport, when set, wraps the transport of the providers calling HTTP APIs,
+// e.g. to record their responses or replay them without network access
+var WrapTransport func(next http.RoundTripper) http.RoundTripper
+
+// newHTTPClient returns the client used by the providers calling HTTP APIs,
+// transport defaults to http.DefaultTransport
+func newHTTPClient(transport http.RoundTripper) *http.Client {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if WrapTransport != nil {
+		transport = WrapTransport(transport)
+	}
+	return &http.Client{Transport: transport}
+}
+
+// builtinProviders lists the providers implemented by this package
+var builtinProviders = []string{"gitlab", "bitbucket", "gitea", "azure", "ldap", "composite"}
+
+// builtinSettings lists the settings accepted by each builtin provider
+var builtinSettings = map[string][]string{
+	"gitlab":    {"token-type", "ca-file", "cert-file", "key-file", "proxy"},
+	"bitbucket": {"workspace"},
+	"gitea":     {},
+	"azure":     {},
+	"ldap":      {"bind-dn", "user-base-dn", "user-filter", "user-attribute", "group-base-dn", "group-filter", "group-attribute", "member-attribute"},
+	"composite": {},
+}
+
+// SettingNames returns the settings accepted by the provider, false for plugins, which accept any setting
+func SettingNames(provider string) ([]string, bool) {
+	names, ok := builtinSettings[provider]
+	return names, ok
+}
+
+// checkSettings returns an error for the first setting the provider doesn't accept
+func checkSettings(provider string, settings Settings) error {
+	names, ok := SettingNames(provider)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		known := false
+		for _, name := range names {
+			if name == key {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("Unknown setting %s for provider %s, valid settings: %v", key, provider, names)
+		}
+	}
+	return nil
+}
+
+// ListProviders returns the builtin providers followed by the plugins found on PATH
 func ListProviders() []string {
-	return []string{"gitlab"}
+	providers := append([]string{}, builtinProviders...)
+	for _, plugin := range listPlugins() {
+		builtin := false
+		for _, p := range builtinProviders {
+			if p == plugin {
+				builtin = true
+			}
+		}
+		if !builtin {
+			providers = append(providers, plugin)
+		}
+	}
+	return providers
+}
+
+// ResolveOwners looks up the names using the provider batch API when it has one,
+// otherwise asking for each name, when a name is an user it isn't looked up as a group
+func ResolveOwners(p Provider, names []string) (map[string]OwnerInfo, error) {
+	if resolver, ok := p.(BatchResolver); ok {
+		return resolver.ResolveOwners(names)
+	}
+	owners := make(map[string]OwnerInfo)
+	for _, name := range names {
+		user, err := p.UserExists(name)
+		if err != nil {
+			return nil, err
+		}
+		if user {
+			owners[name] = OwnerInfo{User: true}
+			continue
+		}
+		group, err := p.GroupExists(name)
+		if err != nil {
+			return nil, err
+		}
+		owners[name] = OwnerInfo{Group: group}
+	}
+	return owners, nil
 }
 
 func InitProvider(provider string, token string, baseURL string) (Provider, error) {
+	return InitProviderWithSettings(provider, token, baseURL, nil)
+}
+
+// InitProviderWithSettings initializes a provider passing its specific settings
+func InitProviderWithSettings(provider string, token string, baseURL string, settings Settings) (Provider, error) {
+	if err := checkSettings(provider, settings); err != nil {
+		return nil, err
+	}
 	var client Provider
 	switch provider {
 	case "gitlab":
 		client = &Gitlab{
+			Token:     token,
+			BaseURL:   baseURL,
+			TokenType: settings["token-type"],
+			CAFile:    settings["ca-file"],
+			CertFile:  settings["cert-file"],
+			KeyFile:   settings["key-file"],
+			ProxyURL:  settings["proxy"],
+		}
+		if err := client.Init(); err != nil {
+			return nil, err
+		}
+	case "bitbucket":
+		client = &Bitbucket{
+			Token:     token,
+			BaseURL:   baseURL,
+			Workspace: settings["workspace"],
+		}
+		if err := client.Init(); err != nil {
+			return nil, err
+		}
+	case "gitea":
+		client = &Gitea{
+			Token:   token,
+			BaseURL: baseURL,
+		}
+		if err := client.Init(); err != nil {
+			return nil, err
+		}
+	case "azure":
+		client = &Azure{
 			Token:   token,
 			BaseURL: baseURL,
 		}
 		if err := client.Init(); err != nil {
 			return nil, err
 		}
+	case "ldap":
+		client = &LDAP{
+			Token:           token,
+			BaseURL:         baseURL,
+			BindDN:          settings["bind-dn"],
+			UserBaseDN:      settings["user-base-dn"],
+			UserFilter:      settings["user-filter"],
+			UserAttribute:   settings["user-attribute"],
+			GroupBaseDN:     settings["group-base-dn"],
+			GroupFilter:     settings["group-filter"],
+			GroupAttribute:  settings["group-attribute"],
+			MemberAttribute: settings["member-attribute"],
+		}
+		if err := client.Init(); err != nil {
+			return nil, err
+		}
+	case "composite":
+		// chains other providers, so it's only loaded from its configuration file
+		return nil, fmt.Errorf("Composite provider must be loaded from its configuration file")
 	default:
-		return nil, fmt.Errorf("Invalid provider")
+		path, ok := discoverPlugins()[provider]
+		if !ok {
+			return nil, fmt.Errorf("Invalid provider")
+		}
+		client = &Plugin{
+			Name:     provider,
+			Path:     path,
+			Token:    token,
+			BaseURL:  baseURL,
+			Settings: settings,
+		}
+		if err := client.Init(); err != nil {
+			return nil, err
+		}
 	}
 	return client, nil
 }
+
+// unquoteOwner removes the quotes used to keep owners with spaces together
+func unquoteOwner(name string) string {
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		return name[1 : len(name)-1]
+	}
+	return name
+}
@@ -2,18 +2,54 @@ package providers
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
+	filet "github.com/Flaque/filet"
 	"github.com/stretchr/testify/assert"
 )
 
+type TestCase struct {
+	Expected interface{}
+	Sample   interface{}
+	Name     string
+}
+
+type ReturnWithError struct {
+	Value interface{}
+	Error bool
+}
+
 func TestInitProviderSuccess(t *testing.T) {
+	unsetGitlabCI(t)
+	// plugins installed on the machine aren't builtin providers
+	defer filet.CleanUp(t)
+	t.Setenv("PATH", filet.TmpDir(t, ""))
+	// the ldap provider connects on init
+	server := newLDAPServer(t)
+	defer server.Close()
 	token := "xyz"
-	baseURL := ""
 	for _, p := range ListProviders() {
+		if p == "composite" {
+			// loaded from its configuration file, see TestLoadComposite
+			continue
+		}
 		t.Logf("Validating provider %s", p)
+		baseURL := ""
+		switch p {
+		case "ldap":
+			baseURL = server.URL()
+		case "azure":
+			// the azure provider has no default organization
+			baseURL = "https://vssps.dev.azure.com/contoso"
+		}
 		provider, err := InitProvider(p, token, baseURL)
 		assert.Equal(t, nil, err)
+		if closer, ok := provider.(io.Closer); ok {
+			closer.Close()
+		}
 		_, ok := interface{}(provider).(Provider)
 		assert.Equal(t, true, ok)
 	}
@@ -29,9 +65,17 @@ func TestInitProviderDefault(t *testing.T) {
 	assert.Equal(t, false, ok)
 }
 func TestInitProviderError(t *testing.T) {
+	unsetGitlabCI(t)
+	defer filet.CleanUp(t)
+	t.Setenv("PATH", filet.TmpDir(t, ""))
 	token := ""
 	baseURL := ""
 	for _, p := range ListProviders() {
+		if p == "ldap" || p == "composite" {
+			// ldap binds anonymously without token, see TestLDAPInit,
+			// and composite is loaded from its configuration file
+			continue
+		}
 		t.Logf("Validating provider %s", p)
 		provider, err := InitProvider(p, token, baseURL)
 		assert.Equal(t, fmt.Errorf("Token can't be empty"), err)
@@ -39,3 +83,71 @@ func TestInitProviderError(t *testing.T) {
 		assert.Equal(t, false, ok)
 	}
 }
+
+func TestInitCompositeProvider(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	// a plugin can't take the name of a builtin provider
+	plugin := filepath.Join(dir, PluginPrefix+"composite")
+	filet.File(t, plugin, "")
+	assert.Nil(t, os.Chmod(plugin, 0755))
+	t.Setenv("PATH", dir)
+	assert.Equal(t, builtinProviders, ListProviders())
+	_, err := InitProvider("composite", "xyz", "")
+	assert.Equal(t, fmt.Errorf("Composite provider must be loaded from its configuration file"), err)
+}
+
+func TestInitProviderUnknownSetting(t *testing.T) {
+	_, err := InitProviderWithSettings("ldap", "secret", "", Settings{"user-base-dn": "ou=people", "base-dn": "dc=example"})
+	assert.Equal(t, fmt.Errorf("Unknown setting base-dn for provider ldap, valid settings: [bind-dn user-base-dn user-filter user-attribute group-base-dn group-filter group-attribute member-attribute]"), err)
+	_, err = InitProviderWithSettings("gitea", "xyz", "", Settings{"proxy": "http://proxy:3128"})
+	assert.Equal(t, fmt.Errorf("Unknown setting proxy for provider gitea, valid settings: []"), err)
+}
+
+// batchProvider resolves names in a single call, failing on single lookups
+type batchProvider struct {
+	staticProvider
+	batches int
+}
+
+func (b *batchProvider) UserExists(name string) (bool, error) {
+	return false, fmt.Errorf("single lookups shouldn't be used")
+}
+
+func (b *batchProvider) GroupExists(name string) (bool, error) {
+	return false, fmt.Errorf("single lookups shouldn't be used")
+}
+
+func (b *batchProvider) ResolveOwners(names []string) (map[string]OwnerInfo, error) {
+	b.batches++
+	owners := make(map[string]OwnerInfo)
+	for _, name := range names {
+		user, _ := b.staticProvider.UserExists(name)
+		group, _ := b.staticProvider.GroupExists(name)
+		owners[name] = OwnerInfo{User: user, Group: group}
+	}
+	return owners, nil
+}
+
+func TestResolveOwners(t *testing.T) {
+	expected := map[string]OwnerInfo{
+		"user1":  {User: true},
+		"group1": {Group: true},
+		"other":  {},
+	}
+	names := []string{"user1", "group1", "other"}
+	static := &staticProvider{users: []string{"user1"}, groups: []string{"group1", "user1"}}
+	owners, err := ResolveOwners(static, names)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, owners)
+	assert.Equal(t, 5, static.calls, "users aren't looked up as groups")
+
+	batch := &batchProvider{staticProvider: staticProvider{users: []string{"user1"}, groups: []string{"group1"}}}
+	owners, err = ResolveOwners(batch, names)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, owners)
+	assert.Equal(t, 1, batch.batches)
+
+	_, err = ResolveOwners(&staticProvider{err: fmt.Errorf("connection refused")}, names)
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+}
@@ -0,0 +1,169 @@
+// Package replay records the HTTP responses of the providers to a fixture file
+// and replays them later, so tests and validations can run without network access
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Modes of a Recorder
+const (
+	// ModeRecord sends the requests and saves every response to the fixture file
+	ModeRecord = "record"
+	// ModeReplay answers the requests from the fixture file, never reaching the network
+	ModeReplay = "replay"
+)
+
+// Fixture represents the file holding the recorded interactions
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction represents a request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request identifies a recorded request. Headers aren't recorded so tokens
+// don't end up on the fixtures
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response represents a recorded response
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Recorder records or replays the requests of every transport it wraps
+type Recorder struct {
+	Mode    string
+	File    string
+	fixture Fixture
+	used    []bool
+	mu      sync.Mutex
+}
+
+// New returns a Recorder, loading the fixture file when replaying
+func New(mode string, file string) (*Recorder, error) {
+	r := &Recorder{Mode: mode, File: file}
+	switch mode {
+	case ModeRecord:
+	case ModeReplay:
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't open fixture: %s", err)
+		}
+		if err := json.Unmarshal(content, &r.fixture); err != nil {
+			return nil, fmt.Errorf("Invalid fixture %s: %s", file, err)
+		}
+		r.used = make([]bool, len(r.fixture.Interactions))
+	default:
+		return nil, fmt.Errorf("Invalid mode %s, valid modes: %v", mode, []string{ModeRecord, ModeReplay})
+	}
+	return r, nil
+}
+
+// Wrap returns a transport recording the responses of next, or replaying
+// them without calling next at all
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{recorder: r, next: next}
+}
+
+// transport is the http.RoundTripper returned by Wrap
+type transport struct {
+	recorder *Recorder
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.recorder.Mode == ModeReplay {
+		return t.recorder.replay(req)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	err = t.recorder.record(Interaction{
+		Request:  Request{Method: req.Method, URL: req.URL.String()},
+		Response: Response{Status: resp.StatusCode, Header: header, Body: string(body)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// record appends the interaction to the fixture file, saving it on every
+// request so nothing is lost if the validation exits early
+func (r *Recorder) record(interaction Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Interactions = append(r.fixture.Interactions, interaction)
+	content, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.File, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("Couldn't write fixture: %s", err)
+	}
+	return nil
+}
+
+// replay answers the request with the first recorded response not replayed yet,
+// the last one is reused when the same request is made more times than recorded
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	found := -1
+	for i, interaction := range r.fixture.Interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.URL != req.URL.String() {
+			continue
+		}
+		found = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if found == -1 {
+		return nil, fmt.Errorf("No recorded response for %s %s", req.Method, req.URL)
+	}
+	r.used[found] = true
+	recorded := r.fixture.Interactions[found].Response
+	header := recorded.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewBufferString(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
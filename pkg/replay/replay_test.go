@@ -0,0 +1,92 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestCase struct {
+	Expected interface{}
+	Sample   interface{}
+	Name     string
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Nil(t, err)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp.StatusCode, string(body), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	defer filet.CleanUp(t)
+	fixture := filepath.Join(filet.TmpDir(t, ""), "fixture.json")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, "%s %d", r.URL.Path, requests)
+	}))
+
+	recorder, err := New(ModeRecord, fixture)
+	assert.Nil(t, err)
+	client := &http.Client{Transport: recorder.Wrap(nil)}
+	for _, path := range []string{"/users", "/users", "/missing"} {
+		_, _, err := get(t, client, server.URL+path)
+		assert.Nil(t, err)
+	}
+	server.Close()
+	content, err := os.ReadFile(fixture)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(content), "secret"), "credentials shouldn't be recorded")
+
+	recorder, err = New(ModeReplay, fixture)
+	assert.Nil(t, err)
+	client = &http.Client{Transport: recorder.Wrap(nil)}
+	tests := []TestCase{
+		{Name: "first recorded response", Sample: "/users", Expected: []interface{}{200, "/users 1"}},
+		{Name: "responses are replayed in order", Sample: "/users", Expected: []interface{}{200, "/users 2"}},
+		{Name: "last response is reused", Sample: "/users", Expected: []interface{}{200, "/users 2"}},
+		{Name: "status is replayed", Sample: "/missing", Expected: []interface{}{404, "/missing 3"}},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		status, body, err := get(t, client, server.URL+test.Sample.(string))
+		assert.Nil(t, err)
+		assert.Equal(t, test.Expected, []interface{}{status, body})
+	}
+	_, _, err = get(t, client, server.URL+"/groups")
+	assert.Error(t, err, "requests not recorded should fail")
+	assert.Equal(t, 3, requests, "replay shouldn't reach the network")
+}
+
+func TestNew(t *testing.T) {
+	defer filet.CleanUp(t)
+	dir := filet.TmpDir(t, "")
+	_, err := New("non-existent", filepath.Join(dir, "fixture.json"))
+	assert.Error(t, err)
+	_, err = New(ModeReplay, filepath.Join(dir, "non-existent.json"))
+	assert.Error(t, err)
+	filet.File(t, filepath.Join(dir, "invalid.json"), "invalid")
+	_, err = New(ModeReplay, filepath.Join(dir, "invalid.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// GitHub reports findings as pull request comments through the GitHub API,
+// Repository is written as owner/name
+type GitHub struct {
+	Token       string
+	BaseURL     string
+	Repository  string
+	PullRequest int
+	api         *api
+}
+
+// githubComment represents an issue or review comment
+type githubComment struct {
+	ID   int    `json:"id"`
+	Body string `json:"body"`
+	Path string `json:"path"`
+	Line int    `json:"line"`
+}
+
+// githubFile represents a file changed by a pull request
+type githubFile struct {
+	Filename string `json:"filename"`
+	Patch    string `json:"patch"`
+}
+
+// Init checks the GitHub reporter configuration
+func (g *GitHub) Init() error {
+	if g.Token == "" {
+		return fmt.Errorf("Token can't be empty")
+	}
+	if g.Repository == "" || g.PullRequest == 0 {
+		return fmt.Errorf("Repository and pull request are required")
+	}
+	if g.BaseURL == "" {
+		g.BaseURL = "https://api.github.com"
+	}
+	if g.api == nil {
+		g.api = &api{
+			client:  &http.Client{},
+			baseURL: strings.TrimSuffix(g.BaseURL, "/"),
+			header: http.Header{
+				"Authorization": {"Bearer " + g.Token},
+				"Accept":        {"application/vnd.github+json"},
+			},
+		}
+	}
+	return nil
+}
+
+// listComments returns the comments of a paginated endpoint
+func (g *GitHub) listComments(path string) ([]githubComment, error) {
+	var comments []githubComment
+	err := g.api.list(path, func(page json.RawMessage) (int, error) {
+		var paginated []githubComment
+		if err := json.Unmarshal(page, &paginated); err != nil {
+			return 0, err
+		}
+		comments = append(comments, paginated...)
+		return len(paginated), nil
+	})
+	return comments, err
+}
+
+// Report writes the summary comment and a review comment on every line added to
+// the CODEOWNERS file with findings, updating the ones written by previous runs
+func (g *GitHub) Report(name string, filename string, findings []verifier.Finding) error {
+	repo := "/repos/" + g.Repository
+	pull := struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	}{}
+	if err := g.api.do(http.MethodGet, fmt.Sprintf("%s/pulls/%d", repo, g.PullRequest), nil, &pull); err != nil {
+		return err
+	}
+	added := map[int]bool{}
+	err := g.api.list(fmt.Sprintf("%s/pulls/%d/files", repo, g.PullRequest), func(page json.RawMessage) (int, error) {
+		var files []githubFile
+		if err := json.Unmarshal(page, &files); err != nil {
+			return 0, err
+		}
+		for _, file := range files {
+			if file.Filename == filename {
+				added = AddedLines(file.Patch)
+			}
+		}
+		return len(files), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	comments, err := g.listComments(fmt.Sprintf("%s/issues/%d/comments", repo, g.PullRequest))
+	if err != nil {
+		return err
+	}
+	summaryWritten := false
+	for _, comment := range comments {
+		if !strings.HasPrefix(comment.Body, Marker(name)) {
+			continue
+		}
+		summaryWritten = true
+		if body := Summary(name, filename, findings); body != comment.Body {
+			if err := g.api.do(http.MethodPatch, fmt.Sprintf("%s/issues/comments/%d", repo, comment.ID), map[string]string{"body": body}, nil); err != nil {
+				return err
+			}
+		}
+		break
+	}
+	if !summaryWritten && len(findings) > 0 {
+		if err := g.api.do(http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", repo, g.PullRequest), map[string]string{"body": Summary(name, filename, findings)}, nil); err != nil {
+			return err
+		}
+	}
+
+	reviewComments, err := g.listComments(fmt.Sprintf("%s/pulls/%d/comments", repo, g.PullRequest))
+	if err != nil {
+		return err
+	}
+	byLine, lines := inlineFindings(findings, added)
+	for _, comment := range reviewComments {
+		if !strings.HasPrefix(comment.Body, LineMarker(name)) || comment.Path != filename {
+			continue
+		}
+		body := fixedBody(name)
+		if lineFindings, ok := byLine[comment.Line]; ok {
+			body = lineBody(name, lineFindings)
+			delete(byLine, comment.Line)
+		}
+		if body == comment.Body {
+			continue
+		}
+		if err := g.api.do(http.MethodPatch, fmt.Sprintf("%s/pulls/comments/%d", repo, comment.ID), map[string]string{"body": body}, nil); err != nil {
+			return err
+		}
+	}
+	for _, line := range lines {
+		lineFindings, ok := byLine[line]
+		if !ok {
+			continue
+		}
+		comment := map[string]interface{}{
+			"body":      lineBody(name, lineFindings),
+			"commit_id": pull.Head.SHA,
+			"path":      filename,
+			"line":      line,
+			"side":      "RIGHT",
+		}
+		if err := g.api.do(http.MethodPost, fmt.Sprintf("%s/pulls/%d/comments", repo, g.PullRequest), comment, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
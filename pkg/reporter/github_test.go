@@ -0,0 +1,139 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// githubServer is a GitHub stand-in keeping the comments of a single pull request
+type githubServer struct {
+	*httptest.Server
+	mu             sync.Mutex
+	comments       []*githubComment
+	reviewComments []*githubComment
+	commitIDs      []string
+	writes         int
+}
+
+func newGithubServer(t *testing.T) *githubServer {
+	s := &githubServer{}
+	// paginated answers the first page, so the reporter stops paginating
+	paginated := func(w http.ResponseWriter, r *http.Request, items interface{}) {
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		json.NewEncoder(w).Encode(items)
+	}
+	update := func(w http.ResponseWriter, r *http.Request, comments []*githubComment) {
+		id, _ := strconv.Atoi(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		body := &githubComment{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(body))
+		for _, comment := range comments {
+			if comment.ID == id {
+				comment.Body = body.Body
+			}
+		}
+		s.writes++
+		fmt.Fprint(w, "{}")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/org/repo/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xyz", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/vnd.github+json", r.Header.Get("Accept"))
+		fmt.Fprint(w, `{"head": {"sha": "head"}}`)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/7/files", func(w http.ResponseWriter, r *http.Request) {
+		paginated(w, r, []githubFile{{Filename: "README.md", Patch: "@@ -1 +1 @@\n+a\n"}, {Filename: "CODEOWNERS", Patch: codeownersDiff}})
+	})
+	mux.HandleFunc("/repos/org/repo/issues/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Method == http.MethodGet {
+			paginated(w, r, s.comments)
+			return
+		}
+		comment := &githubComment{ID: len(s.comments) + 1}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(comment))
+		s.comments = append(s.comments, comment)
+		s.writes++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "{}")
+	})
+	mux.HandleFunc("/repos/org/repo/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		assert.Equal(t, http.MethodPatch, r.Method)
+		update(w, r, s.comments)
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/7/comments", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Method == http.MethodGet {
+			paginated(w, r, s.reviewComments)
+			return
+		}
+		comment := &struct {
+			githubComment
+			CommitID string `json:"commit_id"`
+			Side     string `json:"side"`
+		}{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(comment))
+		assert.Equal(t, "RIGHT", comment.Side)
+		comment.ID = 100 + len(s.reviewComments)
+		s.reviewComments = append(s.reviewComments, &comment.githubComment)
+		s.commitIDs = append(s.commitIDs, comment.CommitID)
+		s.writes++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "{}")
+	})
+	mux.HandleFunc("/repos/org/repo/pulls/comments/", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		assert.Equal(t, http.MethodPatch, r.Method)
+		update(w, r, s.reviewComments)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func TestGitHubReport(t *testing.T) {
+	server := newGithubServer(t)
+	defer server.Close()
+	g := &GitHub{Token: "xyz", BaseURL: server.URL, Repository: "org/repo", PullRequest: 7}
+	assert.Nil(t, g.Init())
+
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings))
+	assert.Equal(t, 1, len(server.comments))
+	assert.Equal(t, Summary("validate", "CODEOWNERS", testFindings), server.comments[0].Body)
+	assert.Equal(t, []*githubComment{{ID: 100, Body: lineBody("validate", testFindings[:1]), Path: "CODEOWNERS", Line: 3}}, server.reviewComments)
+	assert.Equal(t, []string{"head"}, server.commitIDs)
+
+	t.Log("re-running without changes doesn't write")
+	writes := server.writes
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings))
+	assert.Equal(t, writes, server.writes)
+
+	t.Log("re-running after fixing updates the comments in place")
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings[1:]))
+	assert.Equal(t, 1, len(server.comments))
+	assert.Equal(t, Summary("validate", "CODEOWNERS", testFindings[1:]), server.comments[0].Body)
+	assert.Equal(t, 1, len(server.reviewComments))
+	assert.Equal(t, fixedBody("validate"), server.reviewComments[0].Body)
+}
+
+func TestGitHubInit(t *testing.T) {
+	assert.Error(t, (&GitHub{Repository: "org/repo", PullRequest: 1}).Init())
+	assert.Error(t, (&GitHub{Token: "xyz", Repository: "org/repo"}).Init())
+	g := &GitHub{Token: "xyz", Repository: "org/repo", PullRequest: 1}
+	assert.Nil(t, g.Init())
+	assert.Equal(t, "https://api.github.com", g.BaseURL)
+}
@@ -0,0 +1,203 @@
+package reporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// GitLab reports findings as merge request discussions through the GitLab API
+type GitLab struct {
+	Token        string
+	BaseURL      string
+	Project      string
+	MergeRequest int
+	api          *api
+}
+
+// gitlabDiffRefs identifies the versions of a merge request diff
+type gitlabDiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+// gitlabMergeRequest represents the versions a merge request is diffed on
+type gitlabMergeRequest struct {
+	DiffRefs gitlabDiffRefs `json:"diff_refs"`
+}
+
+// gitlabDiff represents the diff of a file changed by a merge request
+type gitlabDiff struct {
+	NewPath string `json:"new_path"`
+	Diff    string `json:"diff"`
+}
+
+// gitlabChanges represents the changes of a merge request on the deprecated
+// changes API, which truncates large merge requests
+type gitlabChanges struct {
+	Changes []gitlabDiff `json:"changes"`
+}
+
+// gitlabPosition places a discussion on a line of the diff
+type gitlabPosition struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	HeadSHA      string `json:"head_sha"`
+	StartSHA     string `json:"start_sha"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line"`
+}
+
+// gitlabDiscussion represents a merge request discussion
+type gitlabDiscussion struct {
+	ID    string `json:"id"`
+	Notes []struct {
+		ID       int    `json:"id"`
+		Body     string `json:"body"`
+		Position *struct {
+			NewPath string `json:"new_path"`
+			NewLine int    `json:"new_line"`
+		} `json:"position"`
+	} `json:"notes"`
+}
+
+// Init checks the GitLab reporter configuration
+func (g *GitLab) Init() error {
+	if g.Token == "" {
+		return fmt.Errorf("Token can't be empty")
+	}
+	if g.Project == "" || g.MergeRequest == 0 {
+		return fmt.Errorf("Project and merge request are required")
+	}
+	if g.BaseURL == "" {
+		g.BaseURL = "https://gitlab.com/api/v4"
+	}
+	if g.api == nil {
+		g.api = &api{
+			client:  &http.Client{},
+			baseURL: strings.TrimSuffix(g.BaseURL, "/"),
+			header:  http.Header{"Private-Token": {g.Token}},
+		}
+	}
+	return nil
+}
+
+// mergeRequestPath returns the API path of the merge request
+func (g *GitLab) mergeRequestPath() string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d", url.PathEscape(g.Project), g.MergeRequest)
+}
+
+// listDiffs returns the diffs of the files changed by the merge request, falling back
+// to the changes API on servers without the paginated diffs API
+func (g *GitLab) listDiffs() ([]gitlabDiff, error) {
+	var diffs []gitlabDiff
+	err := g.api.list(g.mergeRequestPath()+"/diffs", func(page json.RawMessage) (int, error) {
+		var paginated []gitlabDiff
+		if err := json.Unmarshal(page, &paginated); err != nil {
+			return 0, err
+		}
+		diffs = append(diffs, paginated...)
+		return len(paginated), nil
+	})
+	var status *statusError
+	if errors.As(err, &status) && status.StatusCode == http.StatusNotFound && len(diffs) == 0 {
+		changes := &gitlabChanges{}
+		if err := g.api.do(http.MethodGet, g.mergeRequestPath()+"/changes", nil, changes); err != nil {
+			return nil, err
+		}
+		return changes.Changes, nil
+	}
+	return diffs, err
+}
+
+// Report writes the summary discussion and a discussion on every line added to
+// the CODEOWNERS file with findings, updating the ones written by previous runs
+func (g *GitLab) Report(name string, filename string, findings []verifier.Finding) error {
+	mergeRequest := &gitlabMergeRequest{}
+	if err := g.api.do(http.MethodGet, g.mergeRequestPath(), nil, mergeRequest); err != nil {
+		return err
+	}
+	diffs, err := g.listDiffs()
+	if err != nil {
+		return err
+	}
+	added := map[int]bool{}
+	for _, diff := range diffs {
+		if diff.NewPath == filename {
+			added = AddedLines(diff.Diff)
+		}
+	}
+	var discussions []gitlabDiscussion
+	err = g.api.list(g.mergeRequestPath()+"/discussions", func(page json.RawMessage) (int, error) {
+		var paginated []gitlabDiscussion
+		if err := json.Unmarshal(page, &paginated); err != nil {
+			return 0, err
+		}
+		discussions = append(discussions, paginated...)
+		return len(paginated), nil
+	})
+	if err != nil {
+		return err
+	}
+	summaryWritten := false
+	byLine, lines := inlineFindings(findings, added)
+	for _, discussion := range discussions {
+		if len(discussion.Notes) == 0 {
+			continue
+		}
+		note := discussion.Notes[0]
+		body := ""
+		switch {
+		case strings.HasPrefix(note.Body, Marker(name)) && !summaryWritten:
+			body = Summary(name, filename, findings)
+			summaryWritten = true
+		case strings.HasPrefix(note.Body, LineMarker(name)) && note.Position != nil && note.Position.NewPath == filename:
+			body = fixedBody(name)
+			if lineFindings, ok := byLine[note.Position.NewLine]; ok {
+				body = lineBody(name, lineFindings)
+				delete(byLine, note.Position.NewLine)
+			}
+		default:
+			continue
+		}
+		if body == note.Body {
+			continue
+		}
+		path := fmt.Sprintf("%s/discussions/%s/notes/%d", g.mergeRequestPath(), discussion.ID, note.ID)
+		if err := g.api.do(http.MethodPut, path, map[string]string{"body": body}, nil); err != nil {
+			return err
+		}
+	}
+	if !summaryWritten && len(findings) > 0 {
+		if err := g.api.do(http.MethodPost, g.mergeRequestPath()+"/discussions", map[string]string{"body": Summary(name, filename, findings)}, nil); err != nil {
+			return err
+		}
+	}
+	for _, line := range lines {
+		lineFindings, ok := byLine[line]
+		if !ok {
+			continue
+		}
+		discussion := map[string]interface{}{
+			"body": lineBody(name, lineFindings),
+			"position": gitlabPosition{
+				PositionType: "text",
+				BaseSHA:      mergeRequest.DiffRefs.BaseSHA,
+				HeadSHA:      mergeRequest.DiffRefs.HeadSHA,
+				StartSHA:     mergeRequest.DiffRefs.StartSHA,
+				NewPath:      filename,
+				NewLine:      line,
+			},
+		}
+		if err := g.api.do(http.MethodPost, g.mergeRequestPath()+"/discussions", discussion, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gitlabNote is a note kept by the GitLab stand-in
+type gitlabNote struct {
+	ID       int             `json:"id"`
+	Body     string          `json:"body"`
+	Position *gitlabPosition `json:"position,omitempty"`
+}
+
+// gitlabServer is a GitLab stand-in keeping the discussions of a single merge request
+type gitlabServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	discussions map[string][]*gitlabNote
+	order       []string
+	writes      int
+	// legacy answers the diffs API as not found, like servers older than GitLab 15.7
+	legacy bool
+}
+
+func newGitlabServer(t *testing.T) *gitlabServer {
+	s := &gitlabServer{discussions: map[string][]*gitlabNote{}}
+	mux := http.NewServeMux()
+	prefix := "/api/v4/projects/group/project/merge_requests/42"
+	mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "xyz", r.Header.Get("Private-Token"))
+		assert.Contains(t, r.URL.EscapedPath(), "group%2Fproject", "project paths should be escaped")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"diff_refs": map[string]string{"base_sha": "base", "head_sha": "head", "start_sha": "start"},
+		})
+	})
+	// the CODEOWNERS file is on the second page of diffs
+	mux.HandleFunc(prefix+"/diffs", func(w http.ResponseWriter, r *http.Request) {
+		if s.legacy {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		diffs := []map[string]string{{"new_path": "CODEOWNERS", "diff": codeownersDiff}}
+		if r.URL.Query().Get("page") == "1" {
+			diffs = nil
+			for i := 0; i < perPage; i++ {
+				diffs = append(diffs, map[string]string{"new_path": fmt.Sprintf("docs/%d.md", i), "diff": "@@ -1 +1 @@\n+a\n"})
+			}
+		}
+		json.NewEncoder(w).Encode(diffs)
+	})
+	mux.HandleFunc(prefix+"/changes", func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, s.legacy, "the changes API is only used when the diffs API isn't available")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"changes": []map[string]string{{"new_path": "README.md", "diff": "@@ -1 +1 @@\n+a\n"}, {"new_path": "CODEOWNERS", "diff": codeownersDiff}},
+		})
+	})
+	mux.HandleFunc(prefix+"/discussions", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			var discussions []map[string]interface{}
+			if r.URL.Query().Get("page") == "1" {
+				for _, id := range s.order {
+					discussions = append(discussions, map[string]interface{}{"id": id, "notes": s.discussions[id]})
+				}
+			}
+			json.NewEncoder(w).Encode(discussions)
+		case http.MethodPost:
+			note := &gitlabNote{ID: len(s.order) + 1}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(note))
+			id := fmt.Sprintf("discussion%d", note.ID)
+			s.discussions[id] = []*gitlabNote{note}
+			s.order = append(s.order, id)
+			s.writes++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, "{}")
+		}
+	})
+	mux.HandleFunc(prefix+"/discussions/", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix+"/discussions/"), "/")
+		noteID, _ := strconv.Atoi(parts[2])
+		assert.Equal(t, http.MethodPut, r.Method)
+		update := &gitlabNote{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(update))
+		for _, note := range s.discussions[parts[0]] {
+			if note.ID == noteID {
+				note.Body = update.Body
+			}
+		}
+		s.writes++
+		fmt.Fprint(w, "{}")
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *gitlabServer) notes() []*gitlabNote {
+	var notes []*gitlabNote
+	for _, id := range s.order {
+		notes = append(notes, s.discussions[id]...)
+	}
+	return notes
+}
+
+func TestGitLabReport(t *testing.T) {
+	server := newGitlabServer(t)
+	defer server.Close()
+	g := &GitLab{Token: "xyz", BaseURL: server.URL + "/api/v4", Project: "group/project", MergeRequest: 42}
+	assert.Nil(t, g.Init())
+
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings))
+	notes := server.notes()
+	assert.Equal(t, 2, len(notes))
+	assert.Equal(t, Summary("validate", "CODEOWNERS", testFindings), notes[0].Body)
+	assert.Nil(t, notes[0].Position)
+	assert.Equal(t, lineBody("validate", testFindings[:1]), notes[1].Body, "only findings on added lines are attached to the diff")
+	assert.Equal(t, &gitlabPosition{PositionType: "text", BaseSHA: "base", HeadSHA: "head", StartSHA: "start", NewPath: "CODEOWNERS", NewLine: 3}, notes[1].Position)
+
+	t.Log("re-running without changes doesn't write")
+	writes := server.writes
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings))
+	assert.Equal(t, writes, server.writes)
+
+	t.Log("re-running after fixing updates the comments in place")
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings[1:]))
+	notes = server.notes()
+	assert.Equal(t, 2, len(notes))
+	assert.Equal(t, Summary("validate", "CODEOWNERS", testFindings[1:]), notes[0].Body)
+	assert.Equal(t, fixedBody("validate"), notes[1].Body)
+
+	t.Log("other commands have their own comments")
+	assert.Nil(t, g.Report("verify docs", "CODEOWNERS", testFindings[:1]))
+	assert.Equal(t, 4, len(server.notes()))
+
+	t.Log("verifying other paths doesn't overwrite the comments")
+	assert.Nil(t, g.Report("verify api", "CODEOWNERS", testFindings[:1]))
+	notes = server.notes()
+	assert.Equal(t, 6, len(notes))
+	assert.Equal(t, Summary("verify docs", "CODEOWNERS", testFindings[:1]), notes[2].Body)
+	assert.Equal(t, Summary("verify api", "CODEOWNERS", testFindings[:1]), notes[4].Body)
+}
+
+func TestGitLabReportWithoutDiffsAPI(t *testing.T) {
+	server := newGitlabServer(t)
+	server.legacy = true
+	defer server.Close()
+	g := &GitLab{Token: "xyz", BaseURL: server.URL + "/api/v4", Project: "group/project", MergeRequest: 42}
+	assert.Nil(t, g.Init())
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", testFindings))
+	notes := server.notes()
+	assert.Equal(t, 2, len(notes))
+	assert.Equal(t, &gitlabPosition{PositionType: "text", BaseSHA: "base", HeadSHA: "head", StartSHA: "start", NewPath: "CODEOWNERS", NewLine: 3}, notes[1].Position)
+}
+
+func TestGitLabReportWithoutFindings(t *testing.T) {
+	server := newGitlabServer(t)
+	defer server.Close()
+	g := &GitLab{Token: "xyz", BaseURL: server.URL + "/api/v4", Project: "group/project", MergeRequest: 42}
+	assert.Nil(t, g.Init())
+	assert.Nil(t, g.Report("validate", "CODEOWNERS", nil))
+	assert.Equal(t, 0, len(server.notes()), "nothing is written until there are findings")
+}
+
+func TestGitLabInit(t *testing.T) {
+	assert.Error(t, (&GitLab{Project: "group/project", MergeRequest: 1}).Init())
+	assert.Error(t, (&GitLab{Token: "xyz", MergeRequest: 1}).Init())
+	g := &GitLab{Token: "xyz", Project: "group/project", MergeRequest: 1}
+	assert.Nil(t, g.Init())
+	assert.Equal(t, "https://gitlab.com/api/v4", g.BaseURL)
+}
+
+func TestGitLabReportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	g := &GitLab{Token: "xyz", BaseURL: server.URL, Project: "group/project", MergeRequest: 42}
+	assert.Nil(t, g.Init())
+	assert.Error(t, g.Report("validate", "CODEOWNERS", testFindings))
+}
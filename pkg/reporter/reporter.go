@@ -0,0 +1,201 @@
+// Package reporter publishes the findings of the verifier as merge request comments
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+// Reporter publishes the findings of the CODEOWNERS file on a merge request.
+// name identifies the command reporting, e.g. validate or verify <path>, each one having its own comments
+type Reporter interface {
+	Report(name string, filename string, findings []verifier.Finding) error
+}
+
+// Marker identifies the summary comment written for the command, so re-runs
+// update it instead of adding new ones
+func Marker(name string) string {
+	return fmt.Sprintf("<!-- codeowners-verifier:%s -->", name)
+}
+
+// LineMarker identifies the diff comments written for the command
+func LineMarker(name string) string {
+	return fmt.Sprintf("<!-- codeowners-verifier:%s:line -->", name)
+}
+
+// ListReporters returns the platforms findings can be reported to
+func ListReporters() []string {
+	return []string{"github", "gitlab"}
+}
+
+// Summary renders every finding as the body of the merge request comment
+func Summary(name string, filename string, findings []verifier.Finding) string {
+	var b strings.Builder
+	b.WriteString(Marker(name) + "\n")
+	b.WriteString(fmt.Sprintf("### codeowners-verifier %s `%s`\n\n", name, filename))
+	if len(findings) == 0 {
+		b.WriteString("No findings :white_check_mark:\n")
+		return b.String()
+	}
+	b.WriteString("| Severity | Line | Check | Message |\n")
+	b.WriteString("|----------|------|-------|---------|\n")
+	for _, f := range findings {
+		line := "-"
+		if f.Line > 0 {
+			line = strconv.Itoa(f.Line)
+		}
+		b.WriteString(fmt.Sprintf("| %s | %s | `%s` | %s |\n", f.Severity, line, f.Check, strings.ReplaceAll(f.Message, "|", `\|`)))
+	}
+	return b.String()
+}
+
+// lineBody renders the findings of a single line as a diff comment
+func lineBody(name string, findings []verifier.Finding) string {
+	var b strings.Builder
+	b.WriteString(LineMarker(name) + "\n")
+	for _, f := range findings {
+		b.WriteString(fmt.Sprintf("- **%s** `%s`: %s\n", f.Severity, f.Check, f.Message))
+	}
+	return b.String()
+}
+
+// fixedBody replaces the diff comment of a line without findings anymore
+func fixedBody(name string) string {
+	return LineMarker(name) + "\nFixed :white_check_mark:\n"
+}
+
+// Used to find the hunk headers of an unified diff, capturing the first line of the new file
+var hunkRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// AddedLines returns the lines of the new file added by an unified diff
+func AddedLines(diff string) map[int]bool {
+	added := make(map[int]bool)
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		if match := hunkRegex.FindStringSubmatch(text); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			continue
+		}
+		if line == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+"):
+			added[line] = true
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return added
+}
+
+// inlineFindings groups by line the findings on lines added by the change,
+// returning the lines in order
+func inlineFindings(findings []verifier.Finding, added map[int]bool) (map[int][]verifier.Finding, []int) {
+	byLine := make(map[int][]verifier.Finding)
+	var lines []int
+	for _, f := range findings {
+		if !added[f.Line] {
+			continue
+		}
+		if _, ok := byLine[f.Line]; !ok {
+			lines = append(lines, f.Line)
+		}
+		byLine[f.Line] = append(byLine[f.Line], f)
+	}
+	sort.Ints(lines)
+	return byLine, lines
+}
+
+// statusError is returned by the API calls answered with an unexpected status
+type statusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("Error calling %s %s: unexpected status %d", e.Method, e.Path, e.StatusCode)
+}
+
+// api calls a JSON REST API
+type api struct {
+	client  *http.Client
+	baseURL string
+	header  http.Header
+}
+
+// do sends body as JSON, decoding the response into v when it isn't nil
+func (a *api) do(method string, path string, body interface{}, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(content)
+	}
+	req, err := http.NewRequest(method, a.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	for name, values := range a.header {
+		req.Header[name] = values
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{Method: method, Path: path, StatusCode: resp.StatusCode}
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Error decoding %s %s response: %s", method, path, err)
+	}
+	return nil
+}
+
+// perPage is the page size used on paginated endpoints
+const perPage = 100
+
+// list calls a paginated endpoint until a page comes with less than perPage items,
+// decoding every page with decode, which returns how many items the page had
+func (a *api) list(path string, decode func(page json.RawMessage) (int, error)) error {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	for page := 1; ; page++ {
+		var content json.RawMessage
+		if err := a.do(http.MethodGet, fmt.Sprintf("%s%sper_page=%d&page=%d", path, separator, perPage, page), nil, &content); err != nil {
+			return err
+		}
+		count, err := decode(content)
+		if err != nil {
+			return err
+		}
+		if count < perPage {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/verifier"
+)
+
+type TestCase struct {
+	Expected interface{}
+	Sample   interface{}
+	Name     string
+}
+
+// codeownersDiff adds lines 2 and 3 and keeps 1 and 4
+const codeownersDiff = `@@ -1,3 +1,4 @@
+ * @user1
++/docs/ @user2
++/api/ @user3 @user3
+ /db/ @dba
+-/old/ @user4
+`
+
+var testFindings = []verifier.Finding{
+	{Check: verifier.CheckDuplicateOwner, Severity: verifier.SeverityWarning, Line: 3, Pattern: "/api/", Owner: "@user3", Message: "Line 3: owner @user3 is duplicated"},
+	{Check: verifier.CheckOwnerNotFound, Severity: verifier.SeverityError, Line: 4, Pattern: "/db/", Owner: "@dba", Message: "Error parsing line 4: user/group @dba is invalid"},
+	{Check: verifier.CheckFileSize, Severity: verifier.SeverityError, Message: "File is too big | 4 bytes"},
+}
+
+func TestAddedLines(t *testing.T) {
+	tests := []TestCase{
+		{Name: "single hunk", Sample: codeownersDiff, Expected: map[int]bool{2: true, 3: true}},
+		{
+			Name:     "many hunks",
+			Sample:   "@@ -1 +1 @@\n-a\n+b\n@@ -10,2 +10,3 @@\n c\n+d\n e\n",
+			Expected: map[int]bool{1: true, 11: true},
+		},
+		{Name: "empty diff", Sample: "", Expected: map[int]bool{}},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected, AddedLines(test.Sample.(string)))
+	}
+}
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, `<!-- codeowners-verifier:validate -->
+### codeowners-verifier validate `+"`CODEOWNERS`"+`
+
+| Severity | Line | Check | Message |
+|----------|------|-------|---------|
+| warning | 3 | `+"`duplicate-owner`"+` | Line 3: owner @user3 is duplicated |
+| error | 4 | `+"`owner-not-found`"+` | Error parsing line 4: user/group @dba is invalid |
+| error | - | `+"`file-size`"+` | File is too big \| 4 bytes |
+`, Summary("validate", "CODEOWNERS", testFindings))
+	assert.Equal(t, "<!-- codeowners-verifier:verify -->\n### codeowners-verifier verify `CODEOWNERS`\n\nNo findings :white_check_mark:\n", Summary("verify", "CODEOWNERS", nil))
+}
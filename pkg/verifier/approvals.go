@@ -0,0 +1,85 @@
+package verifier
+
+import (
+	"strings"
+)
+
+// ApprovalRule represents a CODEOWNERS entry matching changed files, whose owners
+// must approve the changes. Each section is evaluated independently, so a file
+// can be matched by an entry of every section
+type ApprovalRule struct {
+	Section *Section
+	Rule    *CodeOwner
+	Files   []string
+	// Approvers are the users that approved the changes as owners of the entry
+	Approvers []string
+}
+
+// Satisfied tells if the entry got the approvals required by its section
+func (r *ApprovalRule) Satisfied() bool {
+	return r.Section.Optional || len(r.Approvers) >= r.Section.Approvals
+}
+
+// MemberChecker tells if the user is one of the owners, e.g. belonging to the owner group
+type MemberChecker func(username string, owner string) (bool, error)
+
+// RequiredApprovals returns the entries matching the changed files, using the last
+// matching entry of every section like VerifyCodeowner does for the whole file
+func RequiredApprovals(codeowners []*CodeOwner, sections []*Section, files []string) []*ApprovalRule {
+	var names []string
+	bySection := make(map[string][]*CodeOwner)
+	for _, c := range codeowners {
+		name := strings.ToLower(c.Section)
+		if _, ok := bySection[name]; !ok {
+			names = append(names, name)
+		}
+		bySection[name] = append(bySection[name], c)
+	}
+	var rules []*ApprovalRule
+	byLine := make(map[int]*ApprovalRule)
+	for _, file := range files {
+		for _, name := range names {
+			rule, _ := VerifyCodeowner(bySection[name], file, nil)
+			if rule.Path == "" {
+				continue
+			}
+			if approval, ok := byLine[rule.Line]; ok {
+				approval.Files = append(approval.Files, file)
+				continue
+			}
+			approval := &ApprovalRule{
+				Section: findSection(sections, rule.Section),
+				Rule:    rule,
+				Files:   []string{file},
+			}
+			byLine[rule.Line] = approval
+			rules = append(rules, approval)
+		}
+	}
+	return rules
+}
+
+// CheckApprovals fills the approvers of every rule, an approver counts for a rule
+// when it's one of its owners or isMember tells it belongs to one of them
+func CheckApprovals(rules []*ApprovalRule, approvers []string, isMember MemberChecker) error {
+	for _, rule := range rules {
+		rule.Approvers = nil
+		for _, approver := range approvers {
+			for _, element := range rule.Rule.Owners {
+				owner := strings.TrimPrefix(element, "@")
+				member := owner == approver
+				if !member && isMember != nil {
+					var err error
+					if member, err = isMember(approver, owner); err != nil {
+						return err
+					}
+				}
+				if member {
+					rule.Approvers = append(rule.Approvers, approver)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
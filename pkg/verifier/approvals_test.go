@@ -0,0 +1,97 @@
+package verifier
+
+import (
+	"fmt"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func readApprovalRules(t *testing.T, content string, files []string) []*ApprovalRule {
+	filename := filet.TmpFile(t, "", content).Name()
+	codeowners, err := ReadCodeownersFile(filename)
+	assert.Nil(t, err)
+	sections, err := ReadSections(filename)
+	assert.Nil(t, err)
+	return RequiredApprovals(codeowners, sections, files)
+}
+
+func TestRequiredApprovals(t *testing.T) {
+	defer filet.CleanUp(t)
+	rules := readApprovalRules(t, `* @user1
+*.md @user2
+[Docs][2] @docs
+/docs/
+^[Go]
+*.go @user3
+`, []string{"README.md", "docs/index.md", "main.go"})
+	var summary []string
+	for _, rule := range rules {
+		summary = append(summary, fmt.Sprintf("%s:%d:%v", rule.Section.Name, rule.Rule.Line, rule.Files))
+	}
+	assert.Equal(t, []string{
+		":2:[README.md docs/index.md]",
+		"Docs:4:[docs/index.md]",
+		":1:[main.go]",
+		"Go:6:[main.go]",
+	}, summary)
+	assert.Equal(t, 2, rules[1].Section.Approvals)
+	assert.Equal(t, true, rules[3].Section.Optional)
+}
+
+func TestCheckApprovals(t *testing.T) {
+	defer filet.CleanUp(t)
+	content := `* @user1 @group1
+[Docs][2]
+*.md @user2 @docs
+^[Go]
+*.go @user3
+`
+	isMember := func(username string, owner string) (bool, error) {
+		members := map[string][]string{"group1": {"user4"}, "docs": {"user5", "user6"}}
+		for _, member := range members[owner] {
+			if member == username {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	tests := []TestCase{
+		{
+			Name:     "owners approved every required rule",
+			Sample:   []string{"user1", "user2", "user5"},
+			Expected: []bool{true, true, true},
+		},
+		{
+			Name:     "group members count as owners",
+			Sample:   []string{"user4", "user5", "user6"},
+			Expected: []bool{true, true, true},
+		},
+		{
+			Name:     "section needs two approvals",
+			Sample:   []string{"user1", "user2"},
+			Expected: []bool{true, false, true},
+		},
+		{
+			Name:     "optional section doesn't need approvals",
+			Sample:   []string{},
+			Expected: []bool{false, false, true},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		rules := readApprovalRules(t, content, []string{"README.md", "main.go"})
+		assert.Nil(t, CheckApprovals(rules, test.Sample.([]string), isMember))
+		var satisfied []bool
+		for _, rule := range rules {
+			satisfied = append(satisfied, rule.Satisfied())
+		}
+		assert.Equal(t, test.Expected, satisfied)
+	}
+	rules := readApprovalRules(t, content, []string{"README.md"})
+	err := CheckApprovals(rules, []string{"user9"}, func(username string, owner string) (bool, error) {
+		return false, fmt.Errorf("connection refused")
+	})
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+}
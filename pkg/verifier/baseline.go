@@ -0,0 +1,95 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// BaselineEntry identifies a known finding. Line numbers are left out on purpose,
+// so entries still match after lines are added or removed from the file
+type BaselineEntry struct {
+	Check   string `json:"check"`
+	Pattern string `json:"pattern,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+}
+
+// Baseline represents the known findings that shouldn't fail a validation
+type Baseline struct {
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// key returns the BaselineEntry identifying the finding
+func (f Finding) key() BaselineEntry {
+	return BaselineEntry{
+		Check:   f.Check,
+		Pattern: f.Pattern,
+		Owner:   f.Owner,
+	}
+}
+
+// LoadBaseline reads a Baseline from a JSON file
+func LoadBaseline(filename string) (*Baseline, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open baseline file: %s", err)
+	}
+	baseline := &Baseline{}
+	if err := json.Unmarshal(content, baseline); err != nil {
+		return nil, fmt.Errorf("Invalid baseline file: %s", err)
+	}
+	return baseline, nil
+}
+
+// NewBaseline returns a Baseline containing the given findings
+func NewBaseline(findings []Finding) *Baseline {
+	baseline := &Baseline{Findings: []BaselineEntry{}}
+	for _, f := range findings {
+		baseline.Findings = append(baseline.Findings, f.key())
+	}
+	sort.SliceStable(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.Check != b.Check {
+			return a.Check < b.Check
+		}
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		return a.Owner < b.Owner
+	})
+	return baseline
+}
+
+// Write saves the Baseline as a JSON file
+func (b *Baseline) Write(filename string) error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf("Couldn't write baseline file: %s", err)
+	}
+	return nil
+}
+
+// Filter returns the findings that aren't on the baseline and how many were suppressed.
+// Each baseline entry suppresses a single finding, so new occurrences of a known
+// finding are still reported
+func (b *Baseline) Filter(findings []Finding) ([]Finding, int) {
+	known := make(map[BaselineEntry]int)
+	for _, entry := range b.Findings {
+		known[entry]++
+	}
+	var remaining []Finding
+	suppressed := 0
+	for _, f := range findings {
+		if known[f.key()] > 0 {
+			known[f.key()]--
+			suppressed++
+			continue
+		}
+		remaining = append(remaining, f)
+	}
+	return remaining, suppressed
+}
@@ -0,0 +1,46 @@
+package verifier
+
+import (
+	"path/filepath"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaselineFilter(t *testing.T) {
+	baseline := NewBaseline([]Finding{
+		{Check: CheckOwnerNotFound, Line: 3, Pattern: "/api/", Owner: "@user1", Message: "Error parsing line 3: user/group @user1 is invalid"},
+		{Check: CheckPathNotFound, Line: 4, Pattern: "/old/", Message: "Error parsing line 4, path /old/ does not exist"},
+	})
+	findings := []Finding{
+		// same finding after lines moved around
+		{Check: CheckOwnerNotFound, Line: 10, Pattern: "/api/", Owner: "@user1"},
+		{Check: CheckPathNotFound, Line: 11, Pattern: "/old/"},
+		// new findings
+		{Check: CheckOwnerNotFound, Line: 12, Pattern: "/api/", Owner: "@user1"},
+		{Check: CheckOwnerNotFound, Line: 13, Pattern: "/web/", Owner: "@user1"},
+	}
+	remaining, suppressed := baseline.Filter(findings)
+	assert.Equal(t, 2, suppressed)
+	assert.Equal(t, findings[2:], remaining)
+}
+
+func TestBaselineWriteAndLoad(t *testing.T) {
+	defer filet.CleanUp(t)
+	filename := filepath.Join(filet.TmpDir(t, ""), "baseline.json")
+	baseline := NewBaseline([]Finding{
+		{Check: CheckPathNotFound, Pattern: "/b/"},
+		{Check: CheckOwnerNotFound, Pattern: "/a/", Owner: "@user1"},
+	})
+	assert.Nil(t, baseline.Write(filename))
+	loaded, err := LoadBaseline(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []BaselineEntry{
+		{Check: CheckOwnerNotFound, Pattern: "/a/", Owner: "@user1"},
+		{Check: CheckPathNotFound, Pattern: "/b/"},
+	}, loaded.Findings)
+
+	_, err = LoadBaseline(filepath.Join(filepath.Dir(filename), "non-existent.json"))
+	assert.Error(t, err)
+}
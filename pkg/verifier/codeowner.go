@@ -3,13 +3,13 @@ package verifier
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"unicode"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/topfreegames/codeowners-verifier/pkg/providers"
 )
 
@@ -20,6 +20,10 @@ type CodeOwner struct {
 	Line   int
 	Owners []string
 	Negate bool
+	// Section is the name of the GitLab section the entry belongs to
+	Section string
+	// Suppressions are the directive comments written before the entry
+	Suppressions []*Suppression
 }
 
 // reverseCodeOwners returns an inverted slice
@@ -30,17 +34,57 @@ func reverseCodeOwners(a []*CodeOwner) []*CodeOwner {
 	return a
 }
 
-// Used to remove comment lines
-const commentChars = "^[#]"
+// Used to remove comments, only # starts a comment, [ and ^ are part of
+// glob patterns and owners like [Org]\Team
+const commentChars = "#"
 
-// stripComment uses the commentChars to remove comments from lines
+// Used to find GitLab section headers, e.g. [Section] or ^[Optional Section][2]
+var sectionRegex = regexp.MustCompile(`^\^?\[[^\]]+\]`)
+
+// stripComment uses the commentChars to remove comments from lines,
+// section headers are removed as well
 func stripComment(source string) string {
+	if sectionRegex.MatchString(strings.TrimSpace(source)) {
+		return ""
+	}
+	return stripLineComment(source)
+}
+
+// stripLineComment removes the comment of a line, keeping section headers,
+// used on dialects without sections where [ starts a pattern
+func stripLineComment(source string) string {
 	if cut := strings.IndexAny(source, commentChars); cut >= 0 {
 		return strings.TrimRightFunc(source[:cut], unicode.IsSpace)
 	}
 	return source
 }
 
+// splitFields splits a line around whitespace like strings.Fields, but keeps
+// double quoted owners together, e.g. @"User Name" used by Bitbucket
+func splitFields(line string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				fields = append(fields, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
+
 // hasdifference returns true if there is an element on slice1 that isn't on slice2
 func hasDifference(slice1 []string, slice2 []string) bool {
 	for _, s1Val := range slice1 {
@@ -61,104 +105,230 @@ func hasDifference(slice1 []string, slice2 []string) bool {
 // ReadCodeownersFile reads the file specified by filename
 // and returns a list of CodeOwners strucs, as well as an error
 func ReadCodeownersFile(filename string) ([]*CodeOwner, error) {
-	var codeowners []*CodeOwner
+	codeowners, _, err := readCodeowners(filename, dialects[DefaultDialect])
+	return codeowners, err
+}
+
+// ReadCodeownersFileWithDialect reads the entries and the section headers of the file,
+// section headers are only read when the dialect supports them
+func ReadCodeownersFileWithDialect(filename string, d *Dialect) ([]*CodeOwner, []*Section, error) {
+	return readCodeowners(filename, d)
+}
+
+// readCodeowners reads the entries and the section headers of the file
+func readCodeowners(filename string, d *Dialect) ([]*CodeOwner, []*Section, error) {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, fmt.Errorf("Couldn't open file: %s", err)
+		return nil, nil, fmt.Errorf("Couldn't open file: %s", err)
 	}
 	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	lineNumber := 1
+	return ParseCodeownersWithDialect(file, d)
+}
+
+// ParseCodeowners reads the entries and the section headers of a CODEOWNERS content,
+// entries without owners get the default owners of their section
+func ParseCodeowners(r io.Reader) ([]*CodeOwner, []*Section, error) {
+	return ParseCodeownersWithDialect(r, dialects[DefaultDialect])
+}
+
+// ParseCodeownersWithDialect reads a CODEOWNERS content like ParseCodeowners. On dialects
+// without sections, lines starting with [ are patterns, e.g. [Dd]ocs/, instead of headers
+func ParseCodeownersWithDialect(r io.Reader, d *Dialect) ([]*CodeOwner, []*Section, error) {
+	var codeowners []*CodeOwner
+	var sections []*Section
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	var section *Section
+	// directives waiting for the entry or section written after them
+	var pending []*Suppression
 	for scanner.Scan() {
-		line := strings.Fields(stripComment(scanner.Text()))
+		lineNumber++
+		text := scanner.Text()
+		if s := parseSuppression(text, lineNumber); s != nil {
+			pending = append(pending, s)
+			continue
+		}
+		if trimmed := strings.TrimSpace(text); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// the directives apply to the first line after them that isn't a comment
+		for _, s := range pending {
+			if !s.FileWide && s.Target == 0 {
+				s.Target = lineNumber
+			}
+		}
+		if !d.Sections {
+			line := splitFields(stripLineComment(text))
+			if len(line) == 1 && parseSection(line[0], lineNumber) != nil {
+				// a section header is reported by CheckLimits, it can't be a rule without owners
+				continue
+			}
+		} else if header := parseSection(text, lineNumber); header != nil {
+			section = header
+			section.Suppressions, pending = pending, nil
+			sections = append(sections, section)
+			continue
+		}
+		line := splitFields(stripLineComment(text))
+		if len(line) == 1 && section != nil && len(section.Owners) > 0 {
+			line = append(line, section.Owners...)
+		}
 		if len(line) == 1 {
-			return nil, fmt.Errorf("Invalid CODEOWNERS entry: %d", lineNumber)
+			return nil, nil, fmt.Errorf("Invalid CODEOWNERS entry: %d", lineNumber)
 		} else if len(line) >= 2 {
-			regex, negateRegex := getPatternFromLine(line[0])
-			if regex != nil {
-				c := &CodeOwner{
-					Path:   line[0],
-					Regex:  regex,
-					Line:   lineNumber,
-					Owners: line[1:],
-					Negate: negateRegex,
-				}
-				codeowners = append(codeowners, c)
+			regex, negateRegex, err := getPatternFromLine(line[0])
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid CODEOWNERS entry: %d, %s", lineNumber, err)
 			}
+			c := &CodeOwner{
+				Path:   line[0],
+				Regex:  regex,
+				Line:   lineNumber,
+				Owners: line[1:],
+				Negate: negateRegex,
+			}
+			if section != nil {
+				c.Section = section.Name
+			}
+			c.Suppressions, pending = pending, nil
+			codeowners = append(codeowners, c)
 		}
-		lineNumber++
 	}
-	return codeowners, nil
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Couldn't read CODEOWNERS content: %s", err)
+	}
+	// directives after the last entry still apply to the file, or are reported as unused
+	switch {
+	case len(codeowners) > 0:
+		last := codeowners[len(codeowners)-1]
+		last.Suppressions = append(last.Suppressions, pending...)
+	case len(sections) > 0:
+		last := sections[len(sections)-1]
+		last.Suppressions = append(last.Suppressions, pending...)
+	}
+	return codeowners, sections, nil
 }
 
 // ValidateCodeownerFile check if every entry:
 // 1. Has a valid file/path
 // 2. Check if every owner is an user or a group.
 func ValidateCodeownerFile(p providers.Provider, filename string) (bool, error) {
-        var validEntriesCache map[string]int = make(map[string]int)
-	codeowners, err := ReadCodeownersFile(filename)
+	findings, err := CheckCodeownerFile(p, filename)
 	if err != nil {
 		return false, err
 	}
-	valid := true
-        currentDir, _ := os.Getwd()
-        files, _ := FilePathWalkDir(currentDir)
+	LogFindings(findings)
+	return !HasErrors(findings), nil
+}
+
+// CheckCodeownerFile returns a Finding for every entry whose path doesn't
+// match any file or whose owner isn't an user or a group on the provider
+func CheckCodeownerFile(p providers.Provider, filename string) ([]Finding, error) {
+	codeowners, err := ReadCodeownersFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return CheckCodeowners(p, codeowners)
+}
+
+// CheckCodeowners does the same as CheckCodeownerFile on entries already read
+func CheckCodeowners(p providers.Provider, codeowners []*CodeOwner) ([]Finding, error) {
+	owners, err := ResolveCodeowners(p, codeowners)
+	if err != nil {
+		return nil, err
+	}
+	return CheckResolvedCodeowners(codeowners, owners), nil
+}
+
+// ResolveCodeowners looks up every owner of the entries on the provider at once,
+// returning what each one is by name without the leading @
+func ResolveCodeowners(p providers.Provider, codeowners []*CodeOwner) (map[string]providers.OwnerInfo, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, c := range codeowners {
+		for _, element := range c.Owners {
+			owner := strings.TrimPrefix(element, "@")
+			if !seen[owner] {
+				seen[owner] = true
+				names = append(names, owner)
+			}
+		}
+	}
+	return providers.ResolveOwners(p, names)
+}
+
+// CheckResolvedCodeowners does the same as CheckCodeowners with the owners already
+// looked up by ResolveCodeowners
+func CheckResolvedCodeowners(codeowners []*CodeOwner, owners map[string]providers.OwnerInfo) []Finding {
+	var findings []Finding
+	currentDir, _ := os.Getwd()
+	files, _ := FilePathWalkDir(currentDir)
 	for _, c := range codeowners {
 		fileMatches := false
 		for idx := 0; idx < len(files) && !fileMatches; idx++ {
-                        file := files[idx]
+			file := files[idx]
 			fileMatches = c.MatchesPath(file)
 		}
 		if !fileMatches {
-			log.Errorf("Error parsing line %d, path %s does not exist", c.Line, c.Path)
-			valid = false
+			findings = append(findings, Finding{
+				Check:    CheckPathNotFound,
+				Severity: SeverityError,
+				Line:     c.Line,
+				Pattern:  c.Path,
+				Message:  fmt.Sprintf("Error parsing line %d, path %s does not exist", c.Line, c.Path),
+			})
 		}
 		for _, element := range c.Owners {
-			owner := strings.Replace(element, "@", "", 1)
-			_, ok := validEntriesCache[owner]
-			if ok {
+			info := owners[strings.TrimPrefix(element, "@")]
+			if info.User || info.Group {
 				continue
 			}
-			exists, err := p.UserExists(owner)
-			if err != nil {
-				return false, err
-			} else {
-				if !exists {
-					exists, err = p.GroupExists(owner)
-					if err != nil {
-						return false, err
-					} else {
-						if !exists {
-							valid = false
-							log.Errorf("Error parsing line %d: user/group %s is invalid", c.Line, element)
-						} else {
-							validEntriesCache[owner] = 2
-						}
-					}
-				} else {
-					validEntriesCache[owner] = 1
-				}
-			}
+			findings = append(findings, Finding{
+				Check:    CheckOwnerNotFound,
+				Severity: SeverityError,
+				Line:     c.Line,
+				Pattern:  c.Path,
+				Owner:    element,
+				Message:  fmt.Sprintf("Error parsing line %d: user/group %s is invalid", c.Line, element),
+			})
 		}
 	}
-	return valid, nil
+	return findings
+}
+
+// NewCodeOwner returns a CodeOwner entry for the given pattern and owners
+func NewCodeOwner(path string, owners []string) (*CodeOwner, error) {
+	regex, negate, err := getPatternFromLine(path)
+	if err != nil {
+		return nil, err
+	}
+	return &CodeOwner{
+		Path:   path,
+		Regex:  regex,
+		Owners: owners,
+		Negate: negate,
+	}, nil
 }
 
 // getPatternFromLine converts a line to a CODEOWNERS entry
 // This is roughly adapted from https://github.com/sabhiram/go-gitignore
-func getPatternFromLine(line string) (*regexp.Regexp, bool) {
+func getPatternFromLine(line string) (*regexp.Regexp, bool, error) {
 	// Trim OS-specific carriage returns.
 	line = strings.TrimRight(line, "\r")
 
 	// TODO: Handle [Rule 4] which negates the match for patterns leading with "!"
 	negatePattern := false
-	if line[0] == '!' {
+	if strings.HasPrefix(line, "!") {
 		negatePattern = true
 		line = line[1:]
 	}
+	if len(line) == 0 {
+		return nil, false, fmt.Errorf("Invalid empty pattern")
+	}
 
-	// If we encounter a foo/*.blah in a folder, prepend the / char
-	if regexp.MustCompile(`([^\/+])/.*\*\.`).MatchString(line) && line[0] != '/' {
+	// As on gitignore, a slash at the beginning or middle of the pattern anchors it
+	// to the root, e.g. docs/api/ matches /docs/api/ but not /src/docs/api/
+	if line[0] != '/' && strings.Contains(strings.TrimSuffix(line, "/"), "/") {
 		line = "/" + line
 	}
 
@@ -196,14 +366,23 @@ func getPatternFromLine(line string) (*regexp.Regexp, bool) {
 	} else {
 		expr = "^(|.*/)" + expr
 	}
-	pattern, _ := regexp.Compile(expr)
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, false, fmt.Errorf("Invalid pattern %s: %s", line, err)
+	}
 
-	return pattern, negatePattern
+	return pattern, negatePattern, nil
 }
 
 func FilePathWalkDir(root string) ([]string, error) {
 	var files []string
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
 		if !info.IsDir() {
 			// remove current dir from filepath to use regex properly.
 			path := strings.Replace(path, root, "", 1)
@@ -237,7 +416,8 @@ func (co *CodeOwner) MatchesPath(f string) bool {
 
 // VerifyCodeowner check if a line matches any entry on the reversed list of CodeOwners
 func VerifyCodeowner(codeowners []*CodeOwner, filename string, ignore []string) (*CodeOwner, bool) {
-	for _, c := range reverseCodeOwners(codeowners) {
+	// reverse a copy, so the caller's slice keeps its order between calls
+	for _, c := range reverseCodeOwners(append([]*CodeOwner(nil), codeowners...)) {
 		match := c.MatchesPath(filename)
 		if match {
 			return c, hasDifference(c.Owners, ignore)
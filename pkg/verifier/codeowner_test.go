@@ -1,8 +1,11 @@
 package verifier
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	filet "github.com/Flaque/filet"
@@ -99,6 +102,21 @@ func TestStripComment(t *testing.T) {
 			Sample:   "^[SectionThatShouldBeSanitized]",
 			Expected: "",
 		},
+		{
+			Name:     "Checking pattern with [",
+			Sample:   "/docs/[Aa]pi/ @docs",
+			Expected: "/docs/[Aa]pi/ @docs",
+		},
+		{
+			Name:     "Checking pattern with ^ and comment",
+			Sample:   "/src/a^b.go @test # owned by test",
+			Expected: "/src/a^b.go @test",
+		},
+		{
+			Name:     "Checking owner with [",
+			Sample:   `* [Org]\Team`,
+			Expected: `* [Org]\Team`,
+		},
 	}
 
 	for i, test := range tests {
@@ -199,7 +217,7 @@ folder1/*.tf @user4
 					},
 					{
 						Path:   "folder2/*",
-						Regex:  regexp.MustCompile("^(|.*/)folder2/([^/]*)(|/.*)$"),
+						Regex:  regexp.MustCompile("^(|/)folder2/([^/]*)(|/.*)$"),
 						Negate: false,
 						Owners: []string{
 							"@group2",
@@ -252,6 +270,39 @@ folder2/ @user2
 				Error: true,
 			},
 		},
+		{
+			Name: "negation without pattern",
+			Sample: map[string]interface{}{
+				"Filename": "negation-codeowners",
+				"Contents": `* @user1
+! @user2`,
+			},
+			Expected: ReturnWithError{
+				Value: nil,
+				Error: true,
+			},
+		},
+		{
+			Name: "negation of the root",
+			Sample: map[string]interface{}{
+				"Filename": "root-negation-codeowners",
+				"Contents": `!/ @user1`,
+			},
+			Expected: ReturnWithError{
+				Value: []*CodeOwner{
+					{
+						Path:   "!/",
+						Regex:  regexp.MustCompile("^(|/)(|.*)$"),
+						Negate: true,
+						Owners: []string{
+							"@user1",
+						},
+						Line: 1,
+					},
+				},
+				Error: false,
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -585,3 +636,117 @@ func TestValidateCodeownerFileGitlab(t *testing.T) {
 		}
 	}
 }
+
+// batchProvider resolves every owner in a single call, failing on single lookups
+type batchProvider struct {
+	owners  map[string]providers.OwnerInfo
+	batches [][]string
+}
+
+func (b *batchProvider) Init() error {
+	return nil
+}
+
+func (b *batchProvider) UserExists(name string) (bool, error) {
+	return false, fmt.Errorf("single lookups shouldn't be used")
+}
+
+func (b *batchProvider) GroupExists(name string) (bool, error) {
+	return false, fmt.Errorf("single lookups shouldn't be used")
+}
+
+func (b *batchProvider) ResolveOwners(names []string) (map[string]providers.OwnerInfo, error) {
+	b.batches = append(b.batches, names)
+	return b.owners, nil
+}
+
+func TestCheckCodeownerFileBatch(t *testing.T) {
+	defer filet.CleanUp(t)
+	folder := filet.TmpDir(t, "./")
+	filet.TmpFile(t, folder, "")
+	codeowners := filet.TmpFile(t, "", folder+" @user1 @group1\n"+folder+"/ @user1 @user100").Name()
+	p := &batchProvider{owners: map[string]providers.OwnerInfo{
+		"user1":  {User: true},
+		"group1": {Group: true},
+	}}
+	findings, err := CheckCodeownerFile(p, codeowners)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"user1", "group1", "user100"}}, p.batches)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, CheckOwnerNotFound, findings[0].Check)
+	assert.Equal(t, "@user100", findings[0].Owner)
+}
+
+func TestCheckCodeownerFileEmailOwner(t *testing.T) {
+	defer filet.CleanUp(t)
+	folder := filet.TmpDir(t, "./")
+	filet.TmpFile(t, folder, "")
+	codeowners := filet.TmpFile(t, "", folder+" @user1 john@example.com").Name()
+	p := &batchProvider{owners: map[string]providers.OwnerInfo{
+		"user1":            {User: true},
+		"john@example.com": {User: true},
+	}}
+	findings, err := CheckCodeownerFile(p, codeowners)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"user1", "john@example.com"}}, p.batches, "only the leading @ is removed")
+	assert.Equal(t, 0, len(findings))
+}
+
+func TestFilePathWalkDir(t *testing.T) {
+	defer filet.CleanUp(t)
+	root := filet.TmpDir(t, "")
+	for _, f := range []string{"README.md", "api/handler.go", ".git/HEAD", ".gitlab/CODEOWNERS"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, filepath.Dir(f)), 0755))
+		filet.File(t, filepath.Join(root, f), "")
+	}
+	files, err := FilePathWalkDir(root)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/.gitlab/CODEOWNERS", "/README.md", "/api/handler.go"}, files)
+}
+
+func TestParseCodeownersWithDialect(t *testing.T) {
+	content := "[Dd]ocs/ @docs\n[Section]\n*.go @backend # [comment]\n"
+	github, err := GetDialect("github")
+	assert.Nil(t, err)
+	codeowners, sections, err := ParseCodeownersWithDialect(strings.NewReader(content), github)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Section(nil), sections)
+	assert.Equal(t, 2, len(codeowners))
+	assert.Equal(t, "[Dd]ocs/", codeowners[0].Path)
+	assert.Equal(t, []string{"@docs"}, codeowners[0].Owners)
+	assert.Equal(t, true, codeowners[0].MatchesPath("docs/index.md"))
+	assert.Equal(t, "*.go", codeowners[1].Path)
+
+	gitlab, err := GetDialect("gitlab")
+	assert.Nil(t, err)
+	codeowners, sections, err = ParseCodeownersWithDialect(strings.NewReader(content), gitlab)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(sections))
+	assert.Equal(t, "Dd", sections[0].Name)
+	assert.Equal(t, 1, len(codeowners))
+	assert.Equal(t, "Section", codeowners[0].Section)
+}
+
+func TestSplitFields(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "Checking regular line",
+			Sample:   "* @user1  @group1",
+			Expected: []string{"*", "@user1", "@group1"},
+		},
+		{
+			Name:     "Checking bitbucket owners",
+			Sample:   `/api/ @"John Smith" @{developers} john@example.com`,
+			Expected: []string{"/api/", `@"John Smith"`, "@{developers}", "john@example.com"},
+		},
+		{
+			Name:     "Checking empty line",
+			Sample:   "   ",
+			Expected: []string(nil),
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected.([]string), splitFields(test.Sample.(string)))
+	}
+}
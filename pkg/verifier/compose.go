@@ -0,0 +1,357 @@
+package verifier
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FragmentFilename is the name of the CODEOWNERS fragments kept by the sub-projects
+const FragmentFilename = "CODEOWNERS.fragment"
+
+// composedHeader is the first line of the CODEOWNERS files written by Compose
+const composedHeader = "# This file is generated from the " + FragmentFilename + " files by codeowners-verifier compose, don't edit it"
+
+// Fragment is the CODEOWNERS fragment of a sub-project, with patterns relative to its directory
+type Fragment struct {
+	// Dir is the directory of the fragment relative to the repository root, empty for the root
+	Dir     string
+	Content string
+}
+
+// Path returns the path of the fragment relative to the repository root
+func (f *Fragment) Path() string {
+	return path.Join(f.Dir, FragmentFilename)
+}
+
+// FindFragments reads every CODEOWNERS fragment of the repository, parents before their sub-projects
+func FindFragments(root string) ([]*Fragment, error) {
+	var fragments []*Fragment
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if entry.IsDir() || entry.Name() != FragmentFilename {
+			return nil
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		dir, err := filepath.Rel(root, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		if dir == "." {
+			dir = ""
+		}
+		fragments = append(fragments, &Fragment{Dir: filepath.ToSlash(dir), Content: string(content)})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't read CODEOWNERS fragments: %s", err)
+	}
+	sort.Slice(fragments, func(i, j int) bool { return fragments[i].Dir < fragments[j].Dir })
+	return fragments, nil
+}
+
+// RebasePattern rewrites the pattern of a fragment relative to the repository root. Patterns
+// without a slash match at any depth, so they are kept under the directory with **/
+func RebasePattern(dir string, pattern string) string {
+	if dir == "" {
+		return pattern
+	}
+	if strings.HasPrefix(pattern, "!") {
+		return "!" + RebasePattern(dir, pattern[1:])
+	}
+	switch {
+	case pattern == "*" || pattern == "**":
+		return "/" + dir + "/"
+	case strings.HasPrefix(pattern, "/"):
+		return "/" + dir + pattern
+	case strings.Contains(strings.TrimSuffix(pattern, "/"), "/"):
+		return "/" + dir + "/" + pattern
+	default:
+		return "/" + dir + "/**/" + pattern
+	}
+}
+
+// relativePattern rewrites the pattern relative to the directory, the opposite of RebasePattern.
+// Returns false when the pattern isn't restricted to the directory
+func relativePattern(dir string, pattern string) (string, bool) {
+	if strings.HasPrefix(pattern, "!") {
+		relative, ok := relativePattern(dir, pattern[1:])
+		return "!" + relative, ok
+	}
+	pattern = anchorPattern(pattern)
+	if pattern == "/"+dir {
+		return "*", true
+	}
+	prefix := "/" + dir + "/"
+	if !strings.HasPrefix(pattern, prefix) {
+		return "", false
+	}
+	rest := pattern[len(prefix):]
+	switch {
+	case rest == "":
+		return "*", true
+	case strings.HasPrefix(rest, "**/") && rest != "**/" && !strings.Contains(strings.TrimSuffix(rest[3:], "/"), "/"):
+		return rest[3:], true
+	default:
+		return "/" + rest, true
+	}
+}
+
+// anchorPattern prefixes with a slash the patterns relative to the root, the ones with a
+// slash before the end. Patterns matching at any depth are returned as they are
+func anchorPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "/") || strings.HasPrefix(pattern, "**/") || !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return pattern
+	}
+	return "/" + pattern
+}
+
+// mayMatchDir tells if the pattern may match files inside the directory
+func mayMatchDir(pattern string, dir string) bool {
+	pattern = anchorPattern(strings.TrimPrefix(pattern, "!"))
+	if !strings.HasPrefix(pattern, "/") {
+		return true
+	}
+	dirPrefix := "/" + dir + "/"
+	cut := strings.IndexAny(pattern, "*?[")
+	if cut < 0 {
+		return strings.HasPrefix(dirPrefix, strings.TrimSuffix(pattern, "/")+"/")
+	}
+	return strings.HasPrefix(dirPrefix, pattern[:cut]) || strings.HasPrefix(pattern[:cut], dirPrefix)
+}
+
+// isFragmentMarker tells if the comment is the one written by Compose before the lines of a fragment
+func isFragmentMarker(comment string) bool {
+	file := strings.TrimPrefix(comment, "# ")
+	return file != comment && path.Base(file) == FragmentFilename && !strings.ContainsAny(file, " \t")
+}
+
+// fragmentFinding returns a finding on a line of a fragment
+func fragmentFinding(check string, severity Severity, file string, line int, pattern string, message string) Finding {
+	return Finding{
+		Check:    check,
+		Severity: severity,
+		Line:     line,
+		Pattern:  pattern,
+		Message:  fmt.Sprintf("%s:%d: %s", file, line, message),
+	}
+}
+
+// composedGroup holds the lines of the composed file for a section
+type composedGroup struct {
+	header  string
+	section *Section
+	file    string
+	lines   []string
+}
+
+// composedRule is an entry already written on the composed file
+type composedRule struct {
+	file   string
+	line   int
+	owners string
+}
+
+// Compose builds the CODEOWNERS file of the repository from the fragments, with their patterns
+// rebased onto their directories. Fragments are written parents first, so the entries of the
+// sub-projects take precedence. Entries of the same section are written together, since
+// sections can't be closed. Returns an error finding for every conflict between fragments
+func Compose(fragments []*Fragment) (string, []Finding, error) {
+	var findings []Finding
+	groups := []*composedGroup{{}}
+	rules := make(map[string]*composedRule)
+	for _, fragment := range fragments {
+		file := fragment.Path()
+		group := groups[0]
+		// write adds the line to the current group, marking where the lines of the fragment start
+		write := func(line string) {
+			if group.file != file {
+				group.file = file
+				if len(group.lines) > 0 {
+					group.lines = append(group.lines, "")
+				}
+				group.lines = append(group.lines, "# "+file)
+			}
+			group.lines = append(group.lines, line)
+		}
+		scanner := bufio.NewScanner(strings.NewReader(fragment.Content))
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			text := strings.TrimSpace(scanner.Text())
+			if header := parseSection(text, lineNumber); header != nil {
+				group = nil
+				for _, g := range groups[1:] {
+					if strings.EqualFold(g.section.Name, header.Name) {
+						group = g
+					}
+				}
+				definition := strings.TrimSpace(strings.Join(append([]string{sectionHeader(header)}, header.Owners...), " "))
+				if group == nil {
+					group = &composedGroup{header: definition, section: header}
+					groups = append(groups, group)
+				} else if !strings.EqualFold(group.header, definition) {
+					findings = append(findings, fragmentFinding(CheckFragmentConflict, SeverityError, file, lineNumber, "",
+						fmt.Sprintf("section %s conflicts with %s defined on another fragment", definition, group.header)))
+				}
+				continue
+			}
+			content := stripComment(text)
+			fields := splitFields(content)
+			if len(fields) == 0 {
+				if text != "" {
+					write(text)
+				}
+				continue
+			}
+			pattern := RebasePattern(fragment.Dir, fields[0])
+			owners := strings.Join(fields[1:], " ")
+			write(strings.Join(append([]string{pattern}, fields[1:]...), " ") + text[len(content):])
+			key := strings.ToLower(group.header) + "\x00" + pattern
+			if rule, ok := rules[key]; ok && rule.file != file && rule.owners != owners {
+				findings = append(findings, fragmentFinding(CheckFragmentConflict, SeverityError, file, lineNumber, pattern,
+					fmt.Sprintf("%s is owned by %s on %s:%d", pattern, rule.owners, rule.file, rule.line)))
+			}
+			rules[key] = &composedRule{file: file, line: lineNumber, owners: owners}
+			for _, other := range fragments {
+				nested := other.Dir != fragment.Dir && (fragment.Dir == "" || strings.HasPrefix(other.Dir, fragment.Dir+"/"))
+				if nested && strings.HasPrefix(anchorPattern(strings.TrimPrefix(pattern, "!")), "/"+other.Dir+"/") {
+					findings = append(findings, fragmentFinding(CheckFragmentConflict, SeverityError, file, lineNumber, pattern,
+						fmt.Sprintf("%s belongs to sub-project %s, move it to %s", pattern, other.Dir, other.Path())))
+					break
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return "", nil, fmt.Errorf("Couldn't read %s: %s", file, err)
+		}
+	}
+	var b strings.Builder
+	b.WriteString(composedHeader + "\n")
+	for _, group := range groups {
+		if group.section != nil {
+			b.WriteString("\n" + group.header + "\n")
+		}
+		if len(group.lines) > 0 {
+			b.WriteString("\n" + strings.Join(group.lines, "\n") + "\n")
+		}
+	}
+	return b.String(), findings, nil
+}
+
+// Decompose splits the CODEOWNERS content into a fragment for every directory with the entries
+// restricted to it, and a fragment for the repository root with the remaining ones. Comments
+// are kept with the entry after them. Returns a warning for every entry of the root whose
+// precedence changes, since the entries of the sub-projects are composed after the root ones
+func Decompose(r io.Reader, dirs []string) ([]*Fragment, []Finding, error) {
+	var findings []Finding
+	type fragmentContent struct {
+		lines   []string
+		section string
+		// blank tells a blank line was found since the last entry of the fragment
+		blank bool
+	}
+	contents := map[string]*fragmentContent{"": {}}
+	var sorted []string
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if dir == "" || dir == "." {
+			continue
+		}
+		if _, ok := contents[dir]; !ok {
+			contents[dir] = &fragmentContent{}
+			sorted = append(sorted, dir)
+		}
+	}
+	// the deepest directory takes the entries
+	sort.Slice(sorted, func(i, j int) bool { return strings.Count(sorted[i], "/") > strings.Count(sorted[j], "/") })
+	var comments []string
+	section := ""
+	warned := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+		if header := parseSection(text, lineNumber); header != nil {
+			section = text
+			continue
+		}
+		content := stripComment(text)
+		fields := splitFields(content)
+		if len(fields) == 0 {
+			switch {
+			case text == "":
+				for _, fragment := range contents {
+					fragment.blank = true
+				}
+			case text != composedHeader && !isFragmentMarker(text):
+				comments = append(comments, text)
+			}
+			continue
+		}
+		dir := ""
+		pattern := fields[0]
+		for _, candidate := range sorted {
+			if relative, ok := relativePattern(candidate, fields[0]); ok {
+				dir = candidate
+				pattern = relative
+				break
+			}
+		}
+		if dir == "" {
+			for _, candidate := range sorted {
+				if len(contents[candidate].lines) > 0 && !warned[candidate+"\x00"+pattern] && mayMatchDir(pattern, candidate) {
+					warned[candidate+"\x00"+pattern] = true
+					findings = append(findings, Finding{
+						Check:    CheckFragmentOrder,
+						Severity: SeverityWarning,
+						Line:     lineNumber,
+						Pattern:  pattern,
+						Message:  fmt.Sprintf("Line %d: %s may match files of %s, which take precedence over it once composed", lineNumber, pattern, candidate),
+					})
+				}
+			}
+		}
+		fragment := contents[dir]
+		if fragment.section != section {
+			fragment.section = section
+			if len(fragment.lines) > 0 {
+				fragment.lines = append(fragment.lines, "")
+			}
+			fragment.lines = append(fragment.lines, section)
+		} else if fragment.blank && len(fragment.lines) > 0 {
+			fragment.lines = append(fragment.lines, "")
+		}
+		fragment.lines = append(fragment.lines, comments...)
+		fragment.lines = append(fragment.lines, strings.Join(append([]string{pattern}, fields[1:]...), " ")+text[len(content):])
+		comments = nil
+		fragment.blank = false
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("Couldn't read CODEOWNERS content: %s", err)
+	}
+	contents[""].lines = append(contents[""].lines, comments...)
+	sort.Strings(sorted)
+	var fragments []*Fragment
+	for _, dir := range append([]string{""}, sorted...) {
+		if lines := contents[dir].lines; len(lines) > 0 {
+			fragments = append(fragments, &Fragment{Dir: dir, Content: strings.Join(lines, "\n") + "\n"})
+		}
+	}
+	return fragments, findings, nil
+}
@@ -0,0 +1,154 @@
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebasePattern(t *testing.T) {
+	tests := []TestCase{
+		{Name: "Root fragment", Sample: []string{"", "*.go"}, Expected: "*.go"},
+		{Name: "Everything", Sample: []string{"api", "*"}, Expected: "/api/"},
+		{Name: "Anchored", Sample: []string{"api", "/handler.go"}, Expected: "/api/handler.go"},
+		{Name: "Relative path", Sample: []string{"api", "v1/users.go"}, Expected: "/api/v1/users.go"},
+		{Name: "Any depth", Sample: []string{"api", "*.proto"}, Expected: "/api/**/*.proto"},
+		{Name: "Any depth directory", Sample: []string{"api/v1", "docs/"}, Expected: "/api/v1/**/docs/"},
+		{Name: "Negated", Sample: []string{"api", "!/vendor/"}, Expected: "!/api/vendor/"},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.([]string)
+		rebased := RebasePattern(sample[0], sample[1])
+		assert.Equal(t, test.Expected, rebased)
+		if sample[0] != "" {
+			relative, ok := relativePattern(sample[0], rebased)
+			assert.True(t, ok)
+			assert.Equal(t, RebasePattern(sample[0], relative), rebased)
+		}
+	}
+	_, ok := relativePattern("api", "/web/app.js")
+	assert.False(t, ok)
+	_, ok = relativePattern("api", "*.go")
+	assert.False(t, ok)
+}
+
+func TestCompose(t *testing.T) {
+	fragments := []*Fragment{
+		{Dir: "", Content: "# Fallback\n* @default\n\n[Docs] @docs\n*.md\n"},
+		{Dir: "api", Content: "* @backend\n*.proto @backend @api # contracts\n[Docs] @docs\n/README.md @backend\n"},
+		{Dir: "web", Content: "* @frontend\n"},
+	}
+	content, findings, err := Compose(fragments)
+	assert.Nil(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, composedHeader+`
+
+# CODEOWNERS.fragment
+# Fallback
+* @default
+
+# api/CODEOWNERS.fragment
+/api/ @backend
+/api/**/*.proto @backend @api # contracts
+
+# web/CODEOWNERS.fragment
+/web/ @frontend
+
+[Docs] @docs
+
+# CODEOWNERS.fragment
+*.md
+
+# api/CODEOWNERS.fragment
+/api/README.md @backend
+`, content)
+
+	codeowners, _, err := ParseCodeowners(strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Len(t, codeowners, 6)
+
+	_, findings, err = Compose([]*Fragment{
+		{Dir: "", Content: "/api/internal/ @platform\n[Docs][2] @docs\n"},
+		{Dir: "api", Content: "/handler.go @backend\n[docs] @docs\n"},
+		{Dir: "api/internal", Content: "/handler.go @other\n"},
+	})
+	assert.Nil(t, err)
+	var messages []string
+	for _, f := range findings {
+		assert.Equal(t, CheckFragmentConflict, f.Check)
+		assert.Equal(t, SeverityError, f.Severity)
+		messages = append(messages, f.Message)
+	}
+	assert.Equal(t, []string{
+		"CODEOWNERS.fragment:1: /api/internal/ belongs to sub-project api, move it to api/CODEOWNERS.fragment",
+		"api/CODEOWNERS.fragment:2: section [docs] @docs conflicts with [Docs][2] @docs defined on another fragment",
+	}, messages)
+
+	_, findings, err = Compose([]*Fragment{
+		{Dir: "", Content: "/api/handler.go @platform\n"},
+		{Dir: "api", Content: "/handler.go @backend\n"},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, findings, 2)
+	assert.Equal(t, "api/CODEOWNERS.fragment:1: /api/handler.go is owned by @platform on CODEOWNERS.fragment:1", findings[1].Message)
+}
+
+func TestDecompose(t *testing.T) {
+	fragments, findings, err := Decompose(strings.NewReader(`# Fallback
+* @default
+
+# API
+/api/ @backend
+/api/**/*.proto @backend @api # contracts
+/web/app.js @frontend
+*.md @docs
+
+[Security] @security
+/api/auth/
+`), []string{"api/", "web", "api"})
+	assert.Nil(t, err)
+	assert.Equal(t, []*Fragment{
+		{Dir: "", Content: "# Fallback\n* @default\n\n*.md @docs\n"},
+		{Dir: "api", Content: "# API\n* @backend\n*.proto @backend @api # contracts\n\n[Security] @security\n/auth/\n"},
+		{Dir: "web", Content: "/app.js @frontend\n"},
+	}, fragments)
+	var messages []string
+	for _, f := range findings {
+		assert.Equal(t, CheckFragmentOrder, f.Check)
+		messages = append(messages, f.Message)
+	}
+	assert.Equal(t, []string{
+		"Line 8: *.md may match files of api, which take precedence over it once composed",
+		"Line 8: *.md may match files of web, which take precedence over it once composed",
+	}, messages)
+
+	content, _, err := Compose(fragments)
+	assert.Nil(t, err)
+	again, _, err := Decompose(strings.NewReader(content), []string{"api", "web"})
+	assert.Nil(t, err)
+	assert.Equal(t, len(fragments), len(again))
+}
+
+func TestFindFragments(t *testing.T) {
+	defer filet.CleanUp(t)
+	root := filet.TmpDir(t, "")
+	for _, dir := range []string{".git", "api/v1", "web"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	for _, dir := range []string{".git", "", "api/v1", "web", "api"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(root, dir, FragmentFilename), []byte(dir+"\n"), 0644))
+	}
+	fragments, err := FindFragments(root)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Fragment{
+		{Dir: "", Content: "\n"},
+		{Dir: "api", Content: "api\n"},
+		{Dir: "api/v1", Content: "api/v1\n"},
+		{Dir: "web", Content: "web\n"},
+	}, fragments)
+}
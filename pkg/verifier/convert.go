@@ -0,0 +1,161 @@
+package verifier
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Conversion translates a CODEOWNERS file between the dialects of two platforms
+type Conversion struct {
+	From *Dialect
+	To   *Dialect
+	// Owners maps the owners of the source platform to the ones of the target, e.g. @org/team to @group/subgroup
+	Owners map[string]string
+	// warned are the owners already reported as not mapped
+	warned map[string]bool
+}
+
+// LoadOwnerMapping reads the mapping of owners between platforms from a YAML file
+func LoadOwnerMapping(filename string) (map[string]string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open mapping file: %s", err)
+	}
+	owners := make(map[string]string)
+	if err := yaml.Unmarshal(content, &owners); err != nil {
+		return nil, fmt.Errorf("Invalid mapping file: %s", err)
+	}
+	return owners, nil
+}
+
+// warning returns a finding for what can't be translated on the line
+func (c *Conversion) warning(line int, pattern string, owner string, message string) Finding {
+	return Finding{
+		Check:    CheckConversion,
+		Severity: SeverityWarning,
+		Line:     line,
+		Pattern:  pattern,
+		Owner:    owner,
+		Message:  fmt.Sprintf("Line %d: %s", line, message),
+	}
+}
+
+// convertOwner returns the owner on the target platform, empty when it can't be written on it
+func (c *Conversion) convertOwner(owner string, lineNumber int, pattern string) (string, []Finding) {
+	var findings []Finding
+	if mapped, ok := c.Owners[owner]; ok {
+		owner = mapped
+	} else if strings.Contains(owner, "/") && c.From.Name != c.To.Name && !c.warned[owner] {
+		// teams and groups are rarely named the same on both platforms
+		c.warned[owner] = true
+		findings = append(findings, c.warning(lineNumber, pattern, owner, fmt.Sprintf("%s has no mapping, kept as is", owner)))
+	}
+	if c.To.OwnerPattern != nil && !c.To.OwnerPattern.MatchString(owner) {
+		return "", append(findings, c.warning(lineNumber, pattern, owner, fmt.Sprintf("owner %s can't be written on %s, removed", owner, c.To.Name)))
+	}
+	return owner, findings
+}
+
+// Convert reads the CODEOWNERS content on the source dialect and returns it on the target
+// one, keeping comments and blank lines, with a warning for everything that couldn't be
+// translated faithfully. Sections are flattened on targets without them, with their
+// default owners written on the entries without owners
+func (c *Conversion) Convert(r io.Reader) (string, []Finding, error) {
+	var b strings.Builder
+	var findings []Finding
+	var section *Section
+	c.warned = make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		text := scanner.Text()
+		if header := parseSection(text, lineNumber); header != nil && c.From.Sections {
+			section = header
+			if c.To.Sections {
+				var owners []string
+				for _, owner := range header.Owners {
+					converted, warnings := c.convertOwner(owner, lineNumber, "")
+					findings = append(findings, warnings...)
+					if converted != "" {
+						owners = append(owners, converted)
+					}
+				}
+				b.WriteString(strings.TrimSpace(strings.Join(append([]string{sectionHeader(header)}, owners...), " ")) + "\n")
+				continue
+			}
+			lost := "its entries override the previous ones instead of being combined with them"
+			if header.Optional || header.Approvals != 1 {
+				lost += ", and its optional flag and approvals count are lost"
+			}
+			findings = append(findings, c.warning(lineNumber, "", "", fmt.Sprintf("section [%s] isn't supported by %s, %s", header.Name, c.To.Name, lost)))
+			b.WriteString("# " + strings.TrimSpace(text) + "\n")
+			continue
+		}
+		// section headers were handled above, on dialects without sections [ starts a pattern
+		content := stripLineComment(text)
+		fields := splitFields(content)
+		if len(fields) == 0 {
+			b.WriteString(text + "\n")
+			continue
+		}
+		comment := text[len(content):]
+		pattern := fields[0]
+		owners := fields[1:]
+		if len(owners) == 0 && section != nil && !c.To.Sections {
+			owners = section.Owners
+		}
+		var converted []string
+		for _, owner := range owners {
+			owner, warnings := c.convertOwner(owner, lineNumber, pattern)
+			findings = append(findings, warnings...)
+			if owner != "" {
+				converted = append(converted, owner)
+			}
+		}
+		if c.To.Sections && sectionRegex.MatchString(pattern) {
+			pattern = unsectionPattern(pattern)
+		}
+		line := strings.Join(append([]string{pattern}, converted...), " ") + comment
+		switch {
+		case strings.HasPrefix(pattern, "!") && !c.To.Negation:
+			findings = append(findings, c.warning(lineNumber, pattern, "", fmt.Sprintf("negated pattern %s isn't supported by %s, commented out", pattern, c.To.Name)))
+			line = "# " + line
+		case len(converted) == 0 && len(owners) > 0:
+			findings = append(findings, c.warning(lineNumber, pattern, "", fmt.Sprintf("%s has no owners left, commented out", pattern)))
+			line = "# " + line
+		}
+		b.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, fmt.Errorf("Couldn't read CODEOWNERS content: %s", err)
+	}
+	return b.String(), findings, nil
+}
+
+// unsectionPattern rewrites a pattern read as a section header by dialects with sections,
+// e.g. [Dd]ocs/, into one matching the same files: anchored patterns get a leading slash,
+// and the others are matched on any directory with **/
+func unsectionPattern(pattern string) string {
+	if strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+		return "/" + pattern
+	}
+	return "**/" + pattern
+}
+
+// sectionHeader writes the section header without its default owners
+func sectionHeader(s *Section) string {
+	header := fmt.Sprintf("[%s]", s.Name)
+	if s.Optional {
+		header = "^" + header
+	}
+	if s.Approvals != 1 {
+		header += fmt.Sprintf("[%d]", s.Approvals)
+	}
+	return header
+}
@@ -0,0 +1,132 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConversion(t *testing.T, from string, to string, owners map[string]string) *Conversion {
+	fromDialect, err := GetDialect(from)
+	assert.Nil(t, err)
+	toDialect, err := GetDialect(to)
+	assert.Nil(t, err)
+	return &Conversion{From: fromDialect, To: toDialect, Owners: owners}
+}
+
+func TestConvert(t *testing.T) {
+	type conversionResult struct {
+		Content  string
+		Messages []string
+	}
+	tests := []struct {
+		Name       string
+		Conversion *Conversion
+		Sample     string
+		Expected   conversionResult
+	}{
+		{
+			Name:       "GitHub to GitLab with mapping",
+			Conversion: testConversion(t, "github", "gitlab", map[string]string{"@org/backend": "@group/backend"}),
+			Sample: `# Owners
+* @default
+
+/api/ @org/backend @org/api # api team
+/web/ @org/frontend @org/api
+`,
+			Expected: conversionResult{
+				Content: `# Owners
+* @default
+
+/api/ @group/backend @org/api # api team
+/web/ @org/frontend @org/api
+`,
+				Messages: []string{
+					"Line 4: @org/api has no mapping, kept as is",
+					"Line 5: @org/frontend has no mapping, kept as is",
+				},
+			},
+		},
+		{
+			Name:       "GitLab to GitHub flattening sections",
+			Conversion: testConversion(t, "gitlab", "github", map[string]string{"@group/sub/docs": "@org/docs"}),
+			Sample: `* @default
+[Docs] @group/sub/docs
+/docs/
+/docs/api/ @api
+^[Security][2] @security
+/auth/
+!/auth/README.md
+/roles/ @@maintainer
+`,
+			Expected: conversionResult{
+				Content: `* @default
+# [Docs] @group/sub/docs
+/docs/ @org/docs
+/docs/api/ @api
+# ^[Security][2] @security
+/auth/ @security
+# !/auth/README.md @security
+# /roles/
+`,
+				Messages: []string{
+					"Line 2: section [Docs] isn't supported by github, its entries override the previous ones instead of being combined with them",
+					"Line 5: section [Security] isn't supported by github, its entries override the previous ones instead of being combined with them, and its optional flag and approvals count are lost",
+					"Line 7: negated pattern !/auth/README.md isn't supported by github, commented out",
+					"Line 8: owner @@maintainer can't be written on github, removed",
+					"Line 8: /roles/ has no owners left, commented out",
+				},
+			},
+		},
+		{
+			Name:       "GitHub to GitLab with patterns read as sections",
+			Conversion: testConversion(t, "github", "gitlab", nil),
+			Sample: `[Dd]ocs/ @docs
+[Ss]rc/api/ @api
+`,
+			Expected: conversionResult{
+				Content: `**/[Dd]ocs/ @docs
+/[Ss]rc/api/ @api
+`,
+			},
+		},
+		{
+			Name:       "GitLab to GitLab keeping sections",
+			Conversion: testConversion(t, "gitlab", "gitlab", map[string]string{"@old": "@new"}),
+			Sample: `^[Docs][2] @old
+/docs/
+`,
+			Expected: conversionResult{
+				Content: `^[Docs][2] @new
+/docs/
+`,
+			},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		content, findings, err := test.Conversion.Convert(strings.NewReader(test.Sample))
+		assert.Nil(t, err)
+		var messages []string
+		for _, f := range findings {
+			assert.Equal(t, CheckConversion, f.Check)
+			assert.Equal(t, SeverityWarning, f.Severity)
+			messages = append(messages, f.Message)
+		}
+		assert.Equal(t, test.Expected, conversionResult{Content: content, Messages: messages})
+	}
+}
+
+func TestUnsectionPatternMatchesPath(t *testing.T) {
+	files := []string{"docs/index.md", "Docs/index.md", "src/docs/index.md", "src/api/main.go", "lib/src/api/main.go"}
+	for _, pattern := range []string{"[Dd]ocs/", "[Ss]rc/api/"} {
+		expected, _, err := getPatternFromLine(pattern)
+		assert.Nil(t, err)
+		regex, _, err := getPatternFromLine(unsectionPattern(pattern))
+		assert.Nil(t, err)
+		for _, file := range files {
+			assert.Equal(t, expected.MatchString(file), regex.MatchString(file), "%s on %s", pattern, file)
+		}
+	}
+}
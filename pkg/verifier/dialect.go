@@ -0,0 +1,146 @@
+package verifier
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Dialect represents the flavour of CODEOWNERS understood by a platform
+type Dialect struct {
+	Name string
+	// Locations lists where the platform looks for the file, relative to
+	// the repository root and ordered by precedence
+	Locations []string
+	// Sections is true when the platform supports [Section] headers
+	Sections bool
+	// Negation is true when the platform supports excluding paths with !pattern
+	Negation bool
+	Limits   Limits
+	// OwnerPattern matches the owners the platform understands, nil accepts any owner
+	OwnerPattern *regexp.Regexp
+}
+
+// Limits represents the boundaries a platform imposes on a CODEOWNERS file.
+// A zero value means there is no limit
+type Limits struct {
+	// MaxFileSize in bytes, bigger files are ignored by the platform
+	MaxFileSize int
+	// MaxLineLength, MaxRules and MaxOwnersPerRule aren't documented by any platform,
+	// so dialects leave them unset and they are only checked when set with --limit
+	MaxLineLength    int
+	MaxRules         int
+	MaxOwnersPerRule int
+	MaxSections      int
+}
+
+// limitNames maps the names used to override a limit to its field
+var limitNames = map[string]func(*Limits) *int{
+	CheckFileSize:     func(l *Limits) *int { return &l.MaxFileSize },
+	CheckLineLength:   func(l *Limits) *int { return &l.MaxLineLength },
+	CheckRuleCount:    func(l *Limits) *int { return &l.MaxRules },
+	CheckOwnerCount:   func(l *Limits) *int { return &l.MaxOwnersPerRule },
+	CheckSectionCount: func(l *Limits) *int { return &l.MaxSections },
+}
+
+// Override returns a copy of the limits with the given values replaced,
+// keys are the check names, e.g. "owner-count"
+func (l Limits) Override(values map[string]int) (Limits, error) {
+	for name, value := range values {
+		field, ok := limitNames[name]
+		if !ok {
+			return l, fmt.Errorf("Invalid limit %s", name)
+		}
+		*field(&l) = value
+	}
+	return l, nil
+}
+
+// emailOwner matches owners written as e-mails
+const emailOwner = `[^@\s"]+@[^@\s"]+\.[^@\s"]+`
+
+var dialects = map[string]*Dialect{
+	"azure": {
+		Name:      "azure",
+		Locations: []string{"CODEOWNERS", "docs/CODEOWNERS"},
+		// e-mail or [Org]\Team, quoted when it has spaces, optionally prefixed by @
+		OwnerPattern: regexp.MustCompile(`^@?(` + emailOwner + `|\[[^\]]+\]\\[^"\s]+|"\[[^\]]+\]\\[^"]+")$`),
+	},
+	"bitbucket": {
+		Name:      "bitbucket",
+		Locations: []string{"CODEOWNERS", ".bitbucket/CODEOWNERS"},
+		// @user, @{group}, @"User Name" or e-mail
+		OwnerPattern: regexp.MustCompile(`^(@[\w.-]+|@\{[^}]+\}|@"[^"]+"|` + emailOwner + `)$`),
+	},
+	"github": {
+		Name:      "github",
+		Locations: []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"},
+		Limits: Limits{
+			// https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners#codeowners-file-size
+			MaxFileSize: 3 * 1024 * 1024,
+		},
+		// @user, @org/team or e-mail
+		OwnerPattern: regexp.MustCompile(`^(@[\w.-]+(/[\w.-]+)?|` + emailOwner + `)$`),
+	},
+	"gitlab": {
+		Name:      "gitlab",
+		Locations: []string{"CODEOWNERS", "docs/CODEOWNERS", ".gitlab/CODEOWNERS"},
+		Sections:  true,
+		Negation:  true,
+		Limits: Limits{
+			MaxFileSize: 10 * 1024 * 1024,
+			MaxSections: 100,
+		},
+		// @user, @group/subgroup, @@role or e-mail
+		OwnerPattern: regexp.MustCompile(`^(@@?[\w.-]+(/[\w.-]+)*|` + emailOwner + `)$`),
+	},
+}
+
+// DefaultDialect is used when no dialect is specified
+const DefaultDialect = "gitlab"
+
+// ListDialects returns the names of the supported dialects
+func ListDialects() []string {
+	var names []string
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetDialect returns a copy of the dialect registered with the given name
+func GetDialect(name string) (*Dialect, error) {
+	if name == "" {
+		name = DefaultDialect
+	}
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("Invalid dialect %s, valid dialects: %v", name, ListDialects())
+	}
+	copied := *d
+	return &copied, nil
+}
+
+// CheckOwnerSyntax returns a Finding for every owner written in a way the dialect doesn't understand
+func CheckOwnerSyntax(codeowners []*CodeOwner, d *Dialect) []Finding {
+	var findings []Finding
+	if d.OwnerPattern == nil {
+		return nil
+	}
+	for _, c := range codeowners {
+		for _, owner := range c.Owners {
+			if !d.OwnerPattern.MatchString(owner) {
+				findings = append(findings, Finding{
+					Check:    CheckOwnerFormat,
+					Severity: SeverityError,
+					Line:     c.Line,
+					Pattern:  c.Path,
+					Owner:    owner,
+					Message:  fmt.Sprintf("Error parsing line %d: owner %s isn't valid for %s", c.Line, owner, d.Name),
+				})
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,61 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOwnerSyntax(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name: "gitlab owners",
+			Sample: map[string]interface{}{
+				"Dialect": "gitlab",
+				"Owners":  []string{"@user1", "@group/subgroup/team", "@@developer", "user@example.com", "user1", `@"User Name"`},
+			},
+			Expected: []string{"user1", `@"User Name"`},
+		},
+		{
+			Name: "github owners",
+			Sample: map[string]interface{}{
+				"Dialect": "github",
+				"Owners":  []string{"@user1", "@org/team", "user@example.com", "@org/team/subteam"},
+			},
+			Expected: []string{"@org/team/subteam"},
+		},
+		{
+			Name: "bitbucket owners",
+			Sample: map[string]interface{}{
+				"Dialect": "bitbucket",
+				"Owners":  []string{"@user1", "@{developers}", `@"John Smith"`, "user@example.com", "@org/team"},
+			},
+			Expected: []string{"@org/team"},
+		},
+		{
+			Name: "azure owners",
+			Sample: map[string]interface{}{
+				"Dialect": "azure",
+				"Owners":  []string{"john@contoso.com", `[Contoso]\Developers`, `"[Contoso]\Web Team"`, `@"[Contoso]\Web Team"`, "@john", `[Contoso]\Web Team`},
+			},
+			Expected: []string{"@john", "Team"},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		sample := test.Sample.(map[string]interface{})
+		d, err := GetDialect(sample["Dialect"].(string))
+		assert.Nil(t, err)
+		var fields []string
+		for _, owner := range sample["Owners"].([]string) {
+			fields = append(fields, splitFields(owner)...)
+		}
+		findings := CheckOwnerSyntax([]*CodeOwner{{Path: "*", Line: 1, Owners: fields}}, d)
+		var owners []string
+		for _, f := range findings {
+			assert.Equal(t, CheckOwnerFormat, f.Check)
+			owners = append(owners, f.Owner)
+		}
+		assert.Equal(t, test.Expected.([]string), owners)
+	}
+}
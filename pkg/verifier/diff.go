@@ -0,0 +1,241 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OwnershipChange represents files that went from the same owners to the same new owners
+type OwnershipChange struct {
+	Before []string `json:"before"`
+	After  []string `json:"after"`
+	Files  []string `json:"files"`
+}
+
+// ShadowedRule represents an entry matching files that are all owned by later entries
+type ShadowedRule struct {
+	Line    int      `json:"line"`
+	Path    string   `json:"path"`
+	Owners  []string `json:"owners"`
+	Section string   `json:"section,omitempty"`
+	// ShadowedBy are the lines of the entries owning its files
+	ShadowedBy []int `json:"shadowed_by"`
+}
+
+// OwnershipDiff represents the effect of a CODEOWNERS change on the files of the repository
+type OwnershipDiff struct {
+	// Changed are the files that changed owners, including the ones that got owners
+	Changed []*OwnershipChange `json:"changed"`
+	// Unowned are the files that lost all their owners
+	Unowned []*OwnershipChange `json:"unowned"`
+	// Shadowed are the entries shadowed by the change
+	Shadowed []*ShadowedRule `json:"shadowed"`
+}
+
+// EffectiveRules returns the last entry matching the file on every section,
+// like GitLab does when combining sections
+func EffectiveRules(codeowners []*CodeOwner, file string) []*CodeOwner {
+	var names []string
+	bySection := make(map[string]*CodeOwner)
+	for _, c := range codeowners {
+		if !c.MatchesPath(file) {
+			continue
+		}
+		name := strings.ToLower(c.Section)
+		if _, ok := bySection[name]; !ok {
+			names = append(names, name)
+		}
+		bySection[name] = c
+	}
+	var rules []*CodeOwner
+	for _, name := range names {
+		rules = append(rules, bySection[name])
+	}
+	return rules
+}
+
+// EffectiveOwners returns the owners of the file, combining the entries of every section
+func EffectiveOwners(codeowners []*CodeOwner, file string) []string {
+	var owners []string
+	for _, rule := range EffectiveRules(codeowners, file) {
+		for _, owner := range rule.Owners {
+			if !contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// ownersKey identifies a set of owners regardless of the order they are written
+func ownersKey(owners []string) string {
+	sorted := append([]string(nil), owners...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+// shadowedRules returns the entries matching at least one of the files,
+// without being the effective entry of any of them
+func shadowedRules(codeowners []*CodeOwner, files []string) []*ShadowedRule {
+	effective := make(map[string][]*CodeOwner)
+	for _, file := range files {
+		effective[file] = EffectiveRules(codeowners, file)
+	}
+	var shadowed []*ShadowedRule
+	for _, c := range codeowners {
+		matched, owning := false, false
+		var by []int
+		for _, file := range files {
+			if !c.MatchesPath(file) {
+				continue
+			}
+			matched = true
+			for _, rule := range effective[file] {
+				if !strings.EqualFold(rule.Section, c.Section) {
+					continue
+				}
+				if rule == c {
+					owning = true
+				} else if !containsInt(by, rule.Line) {
+					by = append(by, rule.Line)
+				}
+			}
+			if owning {
+				break
+			}
+		}
+		if matched && !owning {
+			sort.Ints(by)
+			shadowed = append(shadowed, &ShadowedRule{Line: c.Line, Path: c.Path, Owners: c.Owners, Section: c.Section, ShadowedBy: by})
+		}
+	}
+	return shadowed
+}
+
+// containsInt tells if the value is on the slice
+func containsInt(slice []int, value int) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleKey identifies an entry between versions of the file, as its line can change
+func ruleKey(section string, path string, owners []string) string {
+	return strings.ToLower(section) + "\x00" + path + "\x00" + strings.Join(owners, " ")
+}
+
+// DiffOwnership compares the effective owners of every file between two versions of
+// the CODEOWNERS entries, and returns the entries shadowed by the new version that
+// weren't shadowed before
+func DiffOwnership(before []*CodeOwner, after []*CodeOwner, files []string) *OwnershipDiff {
+	diff := &OwnershipDiff{}
+	byTransition := make(map[string]*OwnershipChange)
+	for _, file := range files {
+		ownersBefore := EffectiveOwners(before, file)
+		ownersAfter := EffectiveOwners(after, file)
+		if ownersKey(ownersBefore) == ownersKey(ownersAfter) {
+			continue
+		}
+		key := ownersKey(ownersBefore) + "\x00" + ownersKey(ownersAfter)
+		change, ok := byTransition[key]
+		if !ok {
+			change = &OwnershipChange{Before: ownersBefore, After: ownersAfter}
+			byTransition[key] = change
+			if len(ownersAfter) == 0 {
+				diff.Unowned = append(diff.Unowned, change)
+			} else {
+				diff.Changed = append(diff.Changed, change)
+			}
+		}
+		change.Files = append(change.Files, file)
+	}
+	wasShadowed := make(map[string]bool)
+	for _, rule := range shadowedRules(before, files) {
+		wasShadowed[ruleKey(rule.Section, rule.Path, rule.Owners)] = true
+	}
+	for _, rule := range shadowedRules(after, files) {
+		if !wasShadowed[ruleKey(rule.Section, rule.Path, rule.Owners)] {
+			diff.Shadowed = append(diff.Shadowed, rule)
+		}
+	}
+	return diff
+}
+
+// Empty tells if the change doesn't affect the ownership of any file
+func (d *OwnershipDiff) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Unowned) == 0 && len(d.Shadowed) == 0
+}
+
+// maxListedFiles limits the files listed on every row of the markdown summary
+const maxListedFiles = 10
+
+// listFiles renders the files of a markdown row
+func listFiles(files []string) string {
+	var listed []string
+	for idx, file := range files {
+		if idx == maxListedFiles {
+			listed = append(listed, fmt.Sprintf("and %d more", len(files)-maxListedFiles))
+			break
+		}
+		listed = append(listed, "`"+file+"`")
+	}
+	return strings.Join(listed, ", ")
+}
+
+// listOwners renders the owners of a markdown row
+func listOwners(owners []string) string {
+	if len(owners) == 0 {
+		return "_none_"
+	}
+	return strings.Join(owners, " ")
+}
+
+// Markdown renders the diff as a merge request comment
+func (d *OwnershipDiff) Markdown(title string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("### %s\n\n", title))
+	if d.Empty() {
+		b.WriteString("No ownership changes.\n")
+		return b.String()
+	}
+	count := func(changes []*OwnershipChange) int {
+		total := 0
+		for _, change := range changes {
+			total += len(change.Files)
+		}
+		return total
+	}
+	if len(d.Changed) > 0 {
+		b.WriteString(fmt.Sprintf("#### %d files changed owners\n\n", count(d.Changed)))
+		b.WriteString("| Before | After | Files |\n|--------|-------|-------|\n")
+		for _, change := range d.Changed {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", listOwners(change.Before), listOwners(change.After), listFiles(change.Files)))
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Unowned) > 0 {
+		b.WriteString(fmt.Sprintf("#### %d files became unowned\n\n", count(d.Unowned)))
+		b.WriteString("| Before | Files |\n|--------|-------|\n")
+		for _, change := range d.Unowned {
+			b.WriteString(fmt.Sprintf("| %s | %s |\n", listOwners(change.Before), listFiles(change.Files)))
+		}
+		b.WriteString("\n")
+	}
+	if len(d.Shadowed) > 0 {
+		b.WriteString(fmt.Sprintf("#### %d rules became shadowed\n\n", len(d.Shadowed)))
+		b.WriteString("| Line | Rule | Shadowed by lines |\n|------|------|-------------------|\n")
+		for _, rule := range d.Shadowed {
+			var by []string
+			for _, line := range rule.ShadowedBy {
+				by = append(by, fmt.Sprint(line))
+			}
+			b.WriteString(fmt.Sprintf("| %d | `%s %s` | %s |\n", rule.Line, rule.Path, strings.Join(rule.Owners, " "), strings.Join(by, ", ")))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
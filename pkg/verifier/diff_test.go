@@ -0,0 +1,87 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTestCodeowners(t *testing.T, content string) []*CodeOwner {
+	codeowners, _, err := ParseCodeowners(strings.NewReader(content))
+	assert.Nil(t, err)
+	return codeowners
+}
+
+func TestDiffOwnership(t *testing.T) {
+	files := []string{"README.md", "api/handler.go", "api/users.go", "docs/index.md", "web/app.js"}
+	before := parseTestCodeowners(t, `* @default
+/api/ @backend
+/docs/ @docs
+[Web]
+/web/ @frontend
+`)
+	after := parseTestCodeowners(t, `/api/ @backend
+/api/ @api-team
+/docs/ @docs
+*.md @writers
+[Web]
+/web/ @frontend
+`)
+	diff := DiffOwnership(before, after, files)
+	assert.Equal(t, []*OwnershipChange{
+		{Before: []string{"@default"}, After: []string{"@writers"}, Files: []string{"README.md"}},
+		{Before: []string{"@backend"}, After: []string{"@api-team"}, Files: []string{"api/handler.go", "api/users.go"}},
+		{Before: []string{"@docs"}, After: []string{"@writers"}, Files: []string{"docs/index.md"}},
+		{Before: []string{"@default", "@frontend"}, After: []string{"@frontend"}, Files: []string{"web/app.js"}},
+	}, diff.Changed)
+	assert.Equal(t, []*OwnershipChange(nil), diff.Unowned)
+	assert.Equal(t, []*ShadowedRule{
+		{Line: 1, Path: "/api/", Owners: []string{"@backend"}, ShadowedBy: []int{2}},
+		{Line: 3, Path: "/docs/", Owners: []string{"@docs"}, ShadowedBy: []int{4}},
+	}, diff.Shadowed)
+
+	// removing the default owners leaves the files of the web section owned
+	diff = DiffOwnership(before, parseTestCodeowners(t, "/docs/ @docs\n[Web]\n/web/ @frontend\n"), files)
+	assert.Equal(t, []*OwnershipChange{
+		{Before: []string{"@default"}, Files: []string{"README.md"}},
+		{Before: []string{"@backend"}, Files: []string{"api/handler.go", "api/users.go"}},
+	}, diff.Unowned)
+	assert.Equal(t, []*OwnershipChange{
+		{Before: []string{"@default", "@frontend"}, After: []string{"@frontend"}, Files: []string{"web/app.js"}},
+	}, diff.Changed)
+
+	assert.Equal(t, true, DiffOwnership(before, before, files).Empty())
+
+	// reordering the owners of a rule doesn't change the ownership
+	reordered := parseTestCodeowners(t, "* @default\n/api/ @backend @api-team\n")
+	diff = DiffOwnership(reordered, parseTestCodeowners(t, "* @default\n/api/ @api-team @backend\n"), files)
+	assert.Equal(t, true, diff.Empty())
+}
+
+func TestOwnershipDiffMarkdown(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "no changes",
+			Sample:   &OwnershipDiff{},
+			Expected: "### Title\n\nNo ownership changes.\n",
+		},
+		{
+			Name: "every kind of change",
+			Sample: &OwnershipDiff{
+				Changed:  []*OwnershipChange{{After: []string{"@a", "@b"}, Files: []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}}},
+				Unowned:  []*OwnershipChange{{Before: []string{"@c"}, Files: []string{"m"}}},
+				Shadowed: []*ShadowedRule{{Line: 2, Path: "*.go", Owners: []string{"@d"}, ShadowedBy: []int{5, 7}}},
+			},
+			Expected: "### Title\n\n" +
+				"#### 12 files changed owners\n\n| Before | After | Files |\n|--------|-------|-------|\n" +
+				"| _none_ | @a @b | `a`, `b`, `c`, `d`, `e`, `f`, `g`, `h`, `i`, `j`, and 2 more |\n\n" +
+				"#### 1 files became unowned\n\n| Before | Files |\n|--------|-------|\n| @c | `m` |\n\n" +
+				"#### 1 rules became shadowed\n\n| Line | Rule | Shadowed by lines |\n|------|------|-------------------|\n| 2 | `*.go @d` | 5, 7 |\n\n",
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected, test.Sample.(*OwnershipDiff).Markdown("Title"))
+	}
+}
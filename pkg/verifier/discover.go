@@ -0,0 +1,74 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FindRepositoryRoot walks up from dir until it finds a directory containing .git.
+// If none is found, dir itself is returned
+func FindRepositoryRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for current := abs; ; current = filepath.Dir(current) {
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			return current, nil
+		}
+		if filepath.Dir(current) == current {
+			return abs, nil
+		}
+	}
+}
+
+// FindCodeownersFile looks for a CODEOWNERS file on the locations used by the dialect,
+// returning the one the platform would use. A warning is logged for every other
+// candidate found, since the platform ignores them
+func FindCodeownersFile(root string, d *Dialect) (string, error) {
+	found, err := locateCodeownersFile(d, func(location string) bool {
+		info, err := os.Stat(filepath.Join(root, location))
+		return err == nil && !info.IsDir()
+	})
+	if err != nil {
+		return "", fmt.Errorf("Couldn't find a CODEOWNERS file for %s on %s, looked at: %v", d.Name, root, d.Locations)
+	}
+	return filepath.Join(root, found), nil
+}
+
+// FindCodeownersFileIn does the same as FindCodeownersFile on a list of repository files,
+// e.g. the files of a git revision, returning the location relative to the repository root
+func FindCodeownersFileIn(files []string, d *Dialect) (string, error) {
+	listed := make(map[string]bool)
+	for _, file := range files {
+		listed[file] = true
+	}
+	found, err := locateCodeownersFile(d, func(location string) bool {
+		return listed[location]
+	})
+	if err != nil {
+		return "", fmt.Errorf("Couldn't find a CODEOWNERS file for %s, looked at: %v", d.Name, d.Locations)
+	}
+	return found, nil
+}
+
+// locateCodeownersFile returns the first location of the dialect that exists,
+// warning about the other ones
+func locateCodeownersFile(d *Dialect, exists func(location string) bool) (string, error) {
+	var found []string
+	for _, location := range d.Locations {
+		if exists(location) {
+			found = append(found, location)
+		}
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("not found")
+	}
+	for _, ignored := range found[1:] {
+		log.Warnf("Found multiple CODEOWNERS files, %s will be ignored in favor of %s", ignored, found[0])
+	}
+	return found[0], nil
+}
@@ -0,0 +1,94 @@
+package verifier
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCodeownersFile(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name: "github prefers .github over root",
+			Sample: map[string]interface{}{
+				"Dialect": "github",
+				"Files":   []string{"CODEOWNERS", ".github/CODEOWNERS", "docs/CODEOWNERS"},
+			},
+			Expected: ReturnWithError{Value: ".github/CODEOWNERS", Error: false},
+		},
+		{
+			Name: "gitlab prefers root over .gitlab",
+			Sample: map[string]interface{}{
+				"Dialect": "gitlab",
+				"Files":   []string{".gitlab/CODEOWNERS", "CODEOWNERS"},
+			},
+			Expected: ReturnWithError{Value: "CODEOWNERS", Error: false},
+		},
+		{
+			Name: "gitlab falls back to docs",
+			Sample: map[string]interface{}{
+				"Dialect": "gitlab",
+				"Files":   []string{".gitlab/CODEOWNERS", "docs/CODEOWNERS"},
+			},
+			Expected: ReturnWithError{Value: "docs/CODEOWNERS", Error: false},
+		},
+		{
+			Name: "github doesn't look into .gitlab",
+			Sample: map[string]interface{}{
+				"Dialect": "github",
+				"Files":   []string{".gitlab/CODEOWNERS"},
+			},
+			Expected: ReturnWithError{Value: "", Error: true},
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		defer filet.CleanUp(t)
+		sample := test.Sample.(map[string]interface{})
+		expected := test.Expected.(ReturnWithError)
+		root := filet.TmpDir(t, "")
+		for _, f := range sample["Files"].([]string) {
+			assert.Nil(t, os.MkdirAll(filepath.Join(root, filepath.Dir(f)), 0755))
+			filet.File(t, filepath.Join(root, f), "* @user1")
+		}
+		d, err := GetDialect(sample["Dialect"].(string))
+		assert.Nil(t, err)
+		val, err := FindCodeownersFile(root, d)
+		if expected.Error {
+			assert.Error(t, err, "should return an error")
+		} else {
+			assert.Nil(t, err, "should not return error")
+			assert.Equal(t, filepath.Join(root, expected.Value.(string)), val)
+		}
+		val, err = FindCodeownersFileIn(sample["Files"].([]string), d)
+		if expected.Error {
+			assert.Error(t, err, "should return an error")
+		} else {
+			assert.Nil(t, err, "should not return error")
+			assert.Equal(t, expected.Value.(string), val)
+		}
+	}
+}
+
+func TestFindRepositoryRoot(t *testing.T) {
+	defer filet.CleanUp(t)
+	root := filet.TmpDir(t, "")
+	nested := filepath.Join(root, "a", "b")
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, ".git"), 0755))
+	assert.Nil(t, os.MkdirAll(nested, 0755))
+	val, err := FindRepositoryRoot(nested)
+	assert.Nil(t, err)
+	assert.Equal(t, root, val)
+}
+
+func TestGetDialect(t *testing.T) {
+	d, err := GetDialect("")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultDialect, d.Name)
+	_, err = GetDialect("non-existent")
+	assert.Error(t, err)
+}
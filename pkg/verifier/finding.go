@@ -0,0 +1,88 @@
+package verifier
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Severity represents how serious a Finding is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Identifiers of the checks that produce findings
+const (
+	CheckPathNotFound  = "path-not-found"
+	CheckOwnerNotFound = "owner-not-found"
+	CheckFileSize      = "file-size"
+	CheckLineLength    = "line-length"
+	CheckRuleCount     = "rule-count"
+	CheckOwnerCount    = "owner-count"
+	CheckSectionCount  = "section-count"
+	CheckOwnerFormat   = "owner-format"
+	// CheckMissingOwner is reported by verify when a path doesn't have valid owners
+	CheckMissingOwner = "missing-owner"
+	// CheckStaleOwners is reported by audit staleness when the owners stopped committing to their files
+	CheckStaleOwners = "stale-owners"
+	// CheckConversion is reported by convert for what can't be translated faithfully
+	CheckConversion = "conversion"
+	// CheckFragmentConflict is reported by compose when CODEOWNERS fragments disagree
+	CheckFragmentConflict = "fragment-conflict"
+	// CheckFragmentOrder is reported by decompose when the precedence of an entry changes
+	CheckFragmentOrder = "fragment-order"
+	// Lint checks
+	CheckDuplicatePattern   = "duplicate-pattern"
+	CheckEquivalentPattern  = "equivalent-pattern"
+	CheckDuplicateOwner     = "duplicate-owner"
+	CheckUnusedSuppression  = "unused-suppression"
+	CheckInvalidSuppression = "invalid-suppression"
+)
+
+// checks lists the identifiers of the checks, policy rules add their own as policy:<name>
+var checks = []string{
+	CheckPathNotFound, CheckOwnerNotFound, CheckFileSize, CheckLineLength, CheckRuleCount,
+	CheckOwnerCount, CheckSectionCount, CheckOwnerFormat, CheckMissingOwner, CheckStaleOwners,
+	CheckConversion, CheckFragmentConflict, CheckFragmentOrder, CheckDuplicatePattern,
+	CheckEquivalentPattern, CheckDuplicateOwner, CheckUnusedSuppression, CheckInvalidSuppression,
+}
+
+// knownCheck tells if the name identifies a check
+func knownCheck(name string) bool {
+	return contains(checks, name) || (strings.HasPrefix(name, "policy:") && len(name) > len("policy:"))
+}
+
+// Finding represents a problem found on a CODEOWNERS file
+type Finding struct {
+	Check    string
+	Severity Severity
+	// Line is 0 when the finding applies to the whole file
+	Line    int
+	Pattern string
+	Owner   string
+	Message string
+}
+
+// LogFindings logs every finding with the level matching its severity
+func LogFindings(findings []Finding) {
+	for _, f := range findings {
+		if f.Severity == SeverityWarning {
+			log.Warn(f.Message)
+		} else {
+			log.Error(f.Message)
+		}
+	}
+}
+
+// HasErrors returns true if any finding has error severity
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
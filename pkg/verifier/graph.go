@@ -0,0 +1,197 @@
+package verifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Formats of the ownership graph
+const (
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+	GraphJSON    = "json"
+)
+
+// ListGraphFormats returns the formats the ownership graph can be written as
+func ListGraphFormats() []string {
+	return []string{GraphDOT, GraphMermaid, GraphJSON}
+}
+
+// Types of the ownership graph nodes
+const (
+	NodeDirectory = "directory"
+	NodeFile      = "file"
+)
+
+// OwnershipNode represents a directory or a file of the repository with its
+// effective owners. Directories whose files all have the same owners are
+// collapsed, without children
+type OwnershipNode struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// Owners is empty for unowned files and nil when the files have different owners
+	Owners []string `json:"owners"`
+	// Mixed tells the files of the directory have different owners
+	Mixed    bool             `json:"mixed"`
+	Files    int              `json:"files"`
+	Children []*OwnershipNode `json:"children,omitempty"`
+}
+
+// treeDir is a directory of the repository while the ownership graph is built
+type treeDir struct {
+	name  string
+	path  string
+	dirs  map[string]*treeDir
+	files []*OwnershipNode
+}
+
+// BuildOwnershipGraph returns the directory tree of the files annotated with their
+// effective owners, combining the sections like GitLab does. Directories deeper
+// than maxDepth aren't expanded, 0 expands every directory
+func BuildOwnershipGraph(codeowners []*CodeOwner, files []string, maxDepth int) *OwnershipNode {
+	root := &treeDir{dirs: make(map[string]*treeDir)}
+	for _, file := range files {
+		file = strings.TrimPrefix(file, "/")
+		parts := strings.Split(file, "/")
+		dir := root
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := dir.dirs[part]
+			if !ok {
+				sub = &treeDir{name: part, path: dir.path + part + "/", dirs: make(map[string]*treeDir)}
+				dir.dirs[part] = sub
+			}
+			dir = sub
+		}
+		owners := EffectiveOwners(codeowners, file)
+		if owners == nil {
+			owners = []string{}
+		}
+		dir.files = append(dir.files, &OwnershipNode{Name: parts[len(parts)-1], Path: file, Type: NodeFile, Owners: owners, Files: 1})
+	}
+	return root.node(0, maxDepth)
+}
+
+// node converts the directory into a node of the ownership graph, collapsing it when
+// every file has the same owners
+func (d *treeDir) node(depth int, maxDepth int) *OwnershipNode {
+	node := &OwnershipNode{Name: d.name + "/", Path: d.path, Type: NodeDirectory}
+	var names []string
+	for name := range d.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var children []*OwnershipNode
+	for _, name := range names {
+		children = append(children, d.dirs[name].node(depth+1, maxDepth))
+	}
+	sort.Slice(d.files, func(i, j int) bool { return d.files[i].Name < d.files[j].Name })
+	children = append(children, d.files...)
+	uniform := true
+	for _, child := range children {
+		node.Files += child.Files
+		if child.Mixed || ownersKey(child.Owners) != ownersKey(children[0].Owners) {
+			uniform = false
+		}
+	}
+	switch {
+	case len(children) == 0:
+		node.Owners = []string{}
+	case uniform:
+		node.Owners = children[0].Owners
+	case maxDepth > 0 && depth >= maxDepth:
+		node.Mixed = true
+	default:
+		node.Mixed = true
+		node.Children = children
+	}
+	return node
+}
+
+// label describes the node on the DOT and Mermaid graphs
+func (n *OwnershipNode) label() []string {
+	owners := "(mixed owners)"
+	if !n.Mixed {
+		owners = strings.Join(n.Owners, " ")
+		if owners == "" {
+			owners = "(unowned)"
+		}
+	}
+	files := "1 file"
+	if n.Files != 1 {
+		files = fmt.Sprintf("%d files", n.Files)
+	}
+	return []string{n.Name, owners, files}
+}
+
+// walkGraph calls fn for every node of the graph with its id and the id of its parent
+func walkGraph(n *OwnershipNode, fn func(n *OwnershipNode, id string, parent string)) {
+	count := 0
+	var walk func(n *OwnershipNode, parent string)
+	walk = func(n *OwnershipNode, parent string) {
+		id := fmt.Sprintf("n%d", count)
+		count++
+		fn(n, id, parent)
+		for _, child := range n.Children {
+			walk(child, id)
+		}
+	}
+	walk(n, "")
+}
+
+// dotColors are the fill colors given to the owner sets of the DOT graph
+var dotColors = []string{"#cfe2f3", "#d9ead3", "#fff2cc", "#ead1dc", "#d0e0e3", "#fce5cd", "#d9d2e9", "#c9daf8"}
+
+// WriteGraph writes the ownership graph on the format
+func WriteGraph(w io.Writer, root *OwnershipNode, format string) error {
+	var b strings.Builder
+	switch format {
+	case GraphDOT:
+		b.WriteString("digraph ownership {\n  rankdir=LR;\n  node [shape=box, style=filled, fillcolor=\"#ffffff\"];\n")
+		colors := make(map[string]string)
+		walkGraph(root, func(n *OwnershipNode, id string, parent string) {
+			label := strings.ReplaceAll(strings.Join(n.label(), "\n"), `"`, `\"`)
+			label = strings.ReplaceAll(label, "\n", `\n`)
+			attributes := fmt.Sprintf("label=\"%s\"", label)
+			switch {
+			case n.Mixed:
+			case len(n.Owners) == 0:
+				attributes += ", fillcolor=\"#f4cccc\""
+			default:
+				key := ownersKey(n.Owners)
+				if _, ok := colors[key]; !ok {
+					colors[key] = dotColors[len(colors)%len(dotColors)]
+				}
+				attributes += fmt.Sprintf(", fillcolor=\"%s\"", colors[key])
+			}
+			b.WriteString(fmt.Sprintf("  %s [%s];\n", id, attributes))
+			if parent != "" {
+				b.WriteString(fmt.Sprintf("  %s -> %s;\n", parent, id))
+			}
+		})
+		b.WriteString("}\n")
+	case GraphMermaid:
+		b.WriteString("graph LR\n")
+		walkGraph(root, func(n *OwnershipNode, id string, parent string) {
+			label := strings.ReplaceAll(strings.Join(n.label(), "<br/>"), `"`, "#quot;")
+			b.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", id, label))
+			if parent != "" {
+				b.WriteString(fmt.Sprintf("  %s --> %s\n", parent, id))
+			}
+		})
+	case GraphJSON:
+		content, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.Write(content)
+		b.WriteString("\n")
+	default:
+		return fmt.Errorf("Invalid format %s, valid formats: %v", format, ListGraphFormats())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
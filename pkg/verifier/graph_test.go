@@ -0,0 +1,125 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testOwnershipGraph(t *testing.T, maxDepth int) *OwnershipNode {
+	codeowners := parseTestCodeowners(t, `* @default
+/api/ @backend
+/api/legacy/old.go @legacy
+/web/ @frontend
+[Docs]
+/docs/ @docs
+`)
+	files := []string{"README.md", "api/handler.go", "api/v1/users.go", "api/legacy/old.go", "api/legacy/new.go", "web/app.js", "web/css/app.css", "docs/index.md"}
+	return BuildOwnershipGraph(codeowners, files, maxDepth)
+}
+
+// graphSummary renders a line for every node of the graph, indented by depth
+func graphSummary(n *OwnershipNode, depth int) []string {
+	owners := "mixed"
+	if !n.Mixed {
+		owners = strings.Join(n.Owners, " ")
+	}
+	lines := []string{strings.Repeat("  ", depth) + n.Name + " " + owners}
+	for _, child := range n.Children {
+		lines = append(lines, graphSummary(child, depth+1)...)
+	}
+	return lines
+}
+
+func TestBuildOwnershipGraph(t *testing.T) {
+	graph := testOwnershipGraph(t, 0)
+	assert.Equal(t, []string{
+		"/ mixed",
+		"  api/ mixed",
+		"    legacy/ mixed",
+		"      new.go @backend",
+		"      old.go @legacy",
+		"    v1/ @backend",
+		"    handler.go @backend",
+		"  docs/ @default @docs",
+		"  web/ @frontend",
+		"  README.md @default",
+	}, graphSummary(graph, 0))
+	assert.Equal(t, 8, graph.Files)
+	assert.Equal(t, 4, graph.Children[0].Files)
+	assert.Equal(t, "api/legacy/", graph.Children[0].Children[0].Path)
+	assert.Equal(t, NodeFile, graph.Children[3].Type)
+
+	assert.Equal(t, []string{
+		"/ mixed",
+		"  api/ mixed",
+		"  docs/ @default @docs",
+		"  web/ @frontend",
+		"  README.md @default",
+	}, graphSummary(testOwnershipGraph(t, 1), 0))
+
+	graph = BuildOwnershipGraph(parseTestCodeowners(t, "/api/ @backend\n"), []string{"main.go", "api/handler.go"}, 0)
+	assert.Equal(t, []string{"/ mixed", "  api/ @backend", "  main.go "}, graphSummary(graph, 0))
+	assert.Equal(t, []string{}, graph.Children[1].Owners)
+
+	// owners written in a different order are the same owners
+	graph = BuildOwnershipGraph(parseTestCodeowners(t, "/api/ @backend @api\n/web/ @api @backend\n"), []string{"api/handler.go", "web/app.js"}, 0)
+	assert.Equal(t, []string{"/ @backend @api"}, graphSummary(graph, 0))
+}
+
+func TestWriteGraph(t *testing.T) {
+	graph := BuildOwnershipGraph(parseTestCodeowners(t, "/api/ @backend\n/web/ @frontend\n"), []string{"README.md", "api/handler.go", "web/app.js"}, 0)
+	tests := []TestCase{
+		{
+			Name:   GraphDOT,
+			Sample: GraphDOT,
+			Expected: `digraph ownership {
+  rankdir=LR;
+  node [shape=box, style=filled, fillcolor="#ffffff"];
+  n0 [label="/\n(mixed owners)\n3 files"];
+  n1 [label="api/\n@backend\n1 file", fillcolor="#cfe2f3"];
+  n0 -> n1;
+  n2 [label="web/\n@frontend\n1 file", fillcolor="#d9ead3"];
+  n0 -> n2;
+  n3 [label="README.md\n(unowned)\n1 file", fillcolor="#f4cccc"];
+  n0 -> n3;
+}
+`,
+		},
+		{
+			Name:   GraphMermaid,
+			Sample: GraphMermaid,
+			Expected: `graph LR
+  n0["/<br/>(mixed owners)<br/>3 files"]
+  n1["api/<br/>@backend<br/>1 file"]
+  n0 --> n1
+  n2["web/<br/>@frontend<br/>1 file"]
+  n0 --> n2
+  n3["README.md<br/>(unowned)<br/>1 file"]
+  n0 --> n3
+`,
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		var b strings.Builder
+		assert.Nil(t, WriteGraph(&b, graph, test.Sample.(string)))
+		assert.Equal(t, test.Expected, b.String())
+	}
+
+	var b strings.Builder
+	assert.Nil(t, WriteGraph(&b, graph.Children[0], GraphJSON))
+	assert.Equal(t, `{
+  "name": "api/",
+  "path": "api/",
+  "type": "directory",
+  "owners": [
+    "@backend"
+  ],
+  "mixed": false,
+  "files": 1
+}
+`, b.String())
+	assert.NotNil(t, WriteGraph(&b, graph, "svg"))
+}
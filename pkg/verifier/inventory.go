@@ -0,0 +1,165 @@
+package verifier
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats of the ownership inventory
+const (
+	InventoryTable    = "table"
+	InventoryCSV      = "csv"
+	InventoryJSON     = "json"
+	InventoryMarkdown = "markdown"
+)
+
+// ListInventoryFormats returns the formats the inventory can be written as
+func ListInventoryFormats() []string {
+	return []string{InventoryTable, InventoryCSV, InventoryJSON, InventoryMarkdown}
+}
+
+// RuleReference represents an entry granting ownership of files to an owner
+type RuleReference struct {
+	Line  int    `json:"line"`
+	Path  string `json:"path"`
+	Files int    `json:"files"`
+}
+
+// Inventory represents what a user or a group owns on the repository
+type Inventory struct {
+	Owner       string           `json:"owner"`
+	Files       []string         `json:"files"`
+	Directories []string         `json:"directories"`
+	Lines       int              `json:"lines"`
+	Rules       []*RuleReference `json:"rules"`
+}
+
+// LineCounter returns how many lines the file has
+type LineCounter func(file string) (int, error)
+
+// BuildInventory inverts the effective owners of the files, combining the sections like
+// GitLab does, returning what every owner owns sorted by owner. Lines are counted with
+// countLines when it isn't nil
+func BuildInventory(codeowners []*CodeOwner, files []string, countLines LineCounter) ([]*Inventory, error) {
+	byOwner := make(map[string]*Inventory)
+	for _, file := range files {
+		owners := EffectiveOwners(codeowners, file)
+		if len(owners) == 0 {
+			continue
+		}
+		rules := EffectiveRules(codeowners, file)
+		lines := 0
+		if countLines != nil {
+			var err error
+			if lines, err = countLines(file); err != nil {
+				return nil, err
+			}
+		}
+		dir := path.Dir(strings.TrimPrefix(file, "/"))
+		for _, owner := range owners {
+			inventory, ok := byOwner[owner]
+			if !ok {
+				inventory = &Inventory{Owner: owner}
+				byOwner[owner] = inventory
+			}
+			inventory.Files = append(inventory.Files, file)
+			inventory.Lines += lines
+			if !contains(inventory.Directories, dir) {
+				inventory.Directories = append(inventory.Directories, dir)
+			}
+			for _, rule := range rules {
+				if !contains(rule.Owners, owner) {
+					continue
+				}
+				var reference *RuleReference
+				for _, r := range inventory.Rules {
+					if r.Line == rule.Line {
+						reference = r
+					}
+				}
+				if reference == nil {
+					reference = &RuleReference{Line: rule.Line, Path: rule.Path}
+					inventory.Rules = append(inventory.Rules, reference)
+				}
+				reference.Files++
+			}
+		}
+	}
+	var inventories []*Inventory
+	for _, inventory := range byOwner {
+		sort.Strings(inventory.Directories)
+		sort.Slice(inventory.Rules, func(i, j int) bool { return inventory.Rules[i].Line < inventory.Rules[j].Line })
+		inventories = append(inventories, inventory)
+	}
+	sort.Slice(inventories, func(i, j int) bool { return inventories[i].Owner < inventories[j].Owner })
+	return inventories, nil
+}
+
+// inventoryRows returns the header and a row for every owner of the inventory
+func inventoryRows(inventories []*Inventory) [][]string {
+	rows := [][]string{{"Owner", "Files", "Directories", "Lines", "Rules"}}
+	for _, inventory := range inventories {
+		var rules []string
+		for _, r := range inventory.Rules {
+			rules = append(rules, fmt.Sprintf("%d:%s", r.Line, r.Path))
+		}
+		rows = append(rows, []string{
+			inventory.Owner,
+			strconv.Itoa(len(inventory.Files)),
+			strconv.Itoa(len(inventory.Directories)),
+			strconv.Itoa(inventory.Lines),
+			strings.Join(rules, " "),
+		})
+	}
+	return rows
+}
+
+// WriteInventory writes the inventory on the format, the table, CSV and markdown
+// formats summarize every owner on a row, while JSON lists the files as well
+func WriteInventory(w io.Writer, inventories []*Inventory, format string) error {
+	switch format {
+	case InventoryTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, row := range inventoryRows(inventories) {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case InventoryCSV:
+		return csv.NewWriter(w).WriteAll(inventoryRows(inventories))
+	case InventoryJSON:
+		if inventories == nil {
+			inventories = []*Inventory{}
+		}
+		content, err := json.MarshalIndent(inventories, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(content))
+		return err
+	case InventoryMarkdown:
+		rows := inventoryRows(inventories)
+		var b strings.Builder
+		b.WriteString("| " + strings.Join(rows[0], " | ") + " |\n")
+		b.WriteString("|" + strings.Repeat("---|", len(rows[0])) + "\n")
+		for _, row := range rows[1:] {
+			row[0] = "`" + row[0] + "`"
+			if row[4] != "" {
+				row[4] = "`" + row[4] + "`"
+			}
+			for idx := range row {
+				row[idx] = strings.ReplaceAll(row[idx], "|", `\|`)
+			}
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("Invalid format %s, valid formats: %v", format, ListInventoryFormats())
+}
@@ -0,0 +1,138 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testInventory(t *testing.T) []*Inventory {
+	codeowners := parseTestCodeowners(t, `* @default
+/api/ @backend @default
+*.md @docs|team
+`)
+	files := []string{"README.md", "main.go", "api/handler.go", "api/v1/users.go", "api/README.md"}
+	inventories, err := BuildInventory(codeowners, files, func(file string) (int, error) {
+		return len(file), nil
+	})
+	assert.Nil(t, err)
+	return inventories
+}
+
+func TestBuildInventory(t *testing.T) {
+	assert.Equal(t, []*Inventory{
+		{
+			Owner:       "@backend",
+			Files:       []string{"api/handler.go", "api/v1/users.go"},
+			Directories: []string{"api", "api/v1"},
+			Lines:       29,
+			Rules:       []*RuleReference{{Line: 2, Path: "/api/", Files: 2}},
+		},
+		{
+			Owner:       "@default",
+			Files:       []string{"main.go", "api/handler.go", "api/v1/users.go"},
+			Directories: []string{".", "api", "api/v1"},
+			Lines:       36,
+			Rules:       []*RuleReference{{Line: 1, Path: "*", Files: 1}, {Line: 2, Path: "/api/", Files: 2}},
+		},
+		{
+			Owner:       "@docs|team",
+			Files:       []string{"README.md", "api/README.md"},
+			Directories: []string{".", "api"},
+			Lines:       22,
+			Rules:       []*RuleReference{{Line: 3, Path: "*.md", Files: 2}},
+		},
+	}, testInventory(t))
+
+	t.Log("sections are combined like export graph and diff do")
+	inventories, err := BuildInventory(parseTestCodeowners(t, "* @a\n[Docs]\n*.md @d\n"), []string{"README.md", "main.go"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Inventory{
+		{
+			Owner:       "@a",
+			Files:       []string{"README.md", "main.go"},
+			Directories: []string{"."},
+			Rules:       []*RuleReference{{Line: 1, Path: "*", Files: 2}},
+		},
+		{
+			Owner:       "@d",
+			Files:       []string{"README.md"},
+			Directories: []string{"."},
+			Rules:       []*RuleReference{{Line: 3, Path: "*.md", Files: 1}},
+		},
+	}, inventories)
+	assert.Equal(t, []string{"@a", "@d"}, BuildOwnershipGraph(parseTestCodeowners(t, "* @a\n[Docs]\n*.md @d\n"), []string{"README.md"}, 0).Owners)
+
+	_, err = BuildInventory(parseTestCodeowners(t, "* @default\n"), []string{"main.go"}, func(file string) (int, error) {
+		return 0, fmt.Errorf("permission denied")
+	})
+	assert.Equal(t, fmt.Errorf("permission denied"), err)
+}
+
+func TestWriteInventory(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:   InventoryTable,
+			Sample: InventoryTable,
+			Expected: `Owner       Files  Directories  Lines  Rules
+@backend    2      2            29     2:/api/
+@default    3      3            36     1:* 2:/api/
+@docs|team  2      2            22     3:*.md
+`,
+		},
+		{
+			Name:   InventoryCSV,
+			Sample: InventoryCSV,
+			Expected: `Owner,Files,Directories,Lines,Rules
+@backend,2,2,29,2:/api/
+@default,3,3,36,1:* 2:/api/
+@docs|team,2,2,22,3:*.md
+`,
+		},
+		{
+			Name:   InventoryMarkdown,
+			Sample: InventoryMarkdown,
+			Expected: "| Owner | Files | Directories | Lines | Rules |\n|---|---|---|---|---|\n" +
+				"| `@backend` | 2 | 2 | 29 | `2:/api/` |\n" +
+				"| `@default` | 3 | 3 | 36 | `1:* 2:/api/` |\n" +
+				"| `@docs\\|team` | 2 | 2 | 22 | `3:*.md` |\n",
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		var b strings.Builder
+		assert.Nil(t, WriteInventory(&b, testInventory(t), test.Sample.(string)))
+		assert.Equal(t, test.Expected, b.String())
+	}
+
+	var b strings.Builder
+	assert.Nil(t, WriteInventory(&b, testInventory(t)[:1], InventoryJSON))
+	assert.Equal(t, `[
+  {
+    "owner": "@backend",
+    "files": [
+      "api/handler.go",
+      "api/v1/users.go"
+    ],
+    "directories": [
+      "api",
+      "api/v1"
+    ],
+    "lines": 29,
+    "rules": [
+      {
+        "line": 2,
+        "path": "/api/",
+        "files": 2
+      }
+    ]
+  }
+]
+`, b.String())
+	b.Reset()
+	assert.Nil(t, WriteInventory(&b, nil, InventoryJSON))
+	assert.Equal(t, "[]\n", b.String())
+	assert.NotNil(t, WriteInventory(&b, nil, "xml"))
+}
@@ -0,0 +1,80 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// CheckLimits returns a Finding for every platform limit of the dialect
+// that the CODEOWNERS file exceeds
+func CheckLimits(filename string, d *Dialect) ([]Finding, error) {
+	var findings []Finding
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open file: %s", err)
+	}
+	limits := d.Limits
+	if limits.MaxFileSize > 0 && len(content) > limits.MaxFileSize {
+		findings = append(findings, Finding{
+			Check:    CheckFileSize,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("File has %d bytes, %s ignores CODEOWNERS files bigger than %d bytes", len(content), d.Name, limits.MaxFileSize),
+		})
+	}
+	rules, sections := 0, 0
+	for idx, text := range strings.Split(string(content), "\n") {
+		lineNumber := idx + 1
+		text = strings.TrimRight(text, "\r")
+		if length := utf8.RuneCountInString(text); limits.MaxLineLength > 0 && length > limits.MaxLineLength {
+			findings = append(findings, Finding{
+				Check:    CheckLineLength,
+				Severity: SeverityError,
+				Line:     lineNumber,
+				Message:  fmt.Sprintf("Line %d has %d characters, maximum allowed is %d", lineNumber, length, limits.MaxLineLength),
+			})
+		}
+		line := splitFields(stripLineComment(text))
+		// on dialects without sections a line starting with [ is a header only when it
+		// can't be a rule, e.g. [Dd]ocs/ @docs is a pattern
+		if sectionRegex.MatchString(strings.TrimSpace(text)) && (d.Sections || len(line) == 1) {
+			sections++
+			continue
+		}
+		if len(line) == 0 {
+			continue
+		}
+		rules++
+		if limits.MaxOwnersPerRule > 0 && len(line)-1 > limits.MaxOwnersPerRule {
+			findings = append(findings, Finding{
+				Check:    CheckOwnerCount,
+				Severity: SeverityError,
+				Line:     lineNumber,
+				Pattern:  line[0],
+				Message:  fmt.Sprintf("Line %d has %d owners, maximum allowed is %d", lineNumber, len(line)-1, limits.MaxOwnersPerRule),
+			})
+		}
+	}
+	if limits.MaxRules > 0 && rules > limits.MaxRules {
+		findings = append(findings, Finding{
+			Check:    CheckRuleCount,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("File has %d rules, maximum allowed is %d", rules, limits.MaxRules),
+		})
+	}
+	if sections > 0 && !d.Sections {
+		findings = append(findings, Finding{
+			Check:    CheckSectionCount,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("File has %d sections, but %s doesn't support sections", sections, d.Name),
+		})
+	} else if limits.MaxSections > 0 && sections > limits.MaxSections {
+		findings = append(findings, Finding{
+			Check:    CheckSectionCount,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("File has %d sections, maximum allowed is %d", sections, limits.MaxSections),
+		})
+	}
+	return findings, nil
+}
@@ -0,0 +1,116 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLimits(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name: "file within limits",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{MaxFileSize: 100, MaxLineLength: 20, MaxRules: 2, MaxOwnersPerRule: 2, MaxSections: 1},
+				"Sections": true,
+				"Contents": "[Section]\n* @user1 @user2\n# comment\nfolder1 @group1",
+			},
+			Expected: []string(nil),
+		},
+		{
+			Name: "file bigger than allowed",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{MaxFileSize: 10},
+				"Sections": true,
+				"Contents": "* @user1 @user2",
+			},
+			Expected: []string{CheckFileSize},
+		},
+		{
+			Name: "line, rules and owners over the limit",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{MaxLineLength: 20, MaxRules: 1, MaxOwnersPerRule: 2},
+				"Sections": true,
+				"Contents": "* @user1 @user2 @user3\nfolder1 @group1",
+			},
+			Expected: []string{CheckLineLength, CheckOwnerCount, CheckRuleCount},
+		},
+		{
+			Name: "line length counts characters, not bytes",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{MaxLineLength: 20},
+				"Sections": true,
+				"Contents": "/docs/ação/ @usuário",
+			},
+			Expected: []string(nil),
+		},
+		{
+			Name: "too many sections",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{MaxSections: 1},
+				"Sections": true,
+				"Contents": "[Section1]\n* @user1\n^[Section2][2]\n* @user2",
+			},
+			Expected: []string{CheckSectionCount},
+		},
+		{
+			Name: "sections on a dialect without sections",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{},
+				"Sections": false,
+				"Contents": "[Section1]\n* @user1",
+			},
+			Expected: []string{CheckSectionCount},
+		},
+		{
+			Name: "character ranges on a dialect without sections",
+			Sample: map[string]interface{}{
+				"Limits":   Limits{},
+				"Sections": false,
+				"Contents": "[Dd]ocs/ @user1",
+			},
+			Expected: []string(nil),
+		},
+	}
+
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		defer filet.CleanUp(t)
+		sample := test.Sample.(map[string]interface{})
+		file := filet.TmpFile(t, "", sample["Contents"].(string)).Name()
+		d := &Dialect{Name: "test", Sections: sample["Sections"].(bool), Limits: sample["Limits"].(Limits)}
+		findings, err := CheckLimits(file, d)
+		assert.Nil(t, err)
+		var checks []string
+		for _, f := range findings {
+			checks = append(checks, f.Check)
+		}
+		assert.Equal(t, test.Expected.([]string), checks)
+	}
+}
+
+func TestCheckLimitsLongLine(t *testing.T) {
+	defer filet.CleanUp(t)
+	file := filet.TmpFile(t, "", "* @user1\nfolder1 @"+strings.Repeat("a", 100000)).Name()
+	d, err := GetDialect("github")
+	assert.Nil(t, err)
+	findings, err := CheckLimits(file, d)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(findings), "line length isn't a platform limit")
+	d.Limits, err = d.Limits.Override(map[string]int{CheckLineLength: 4096})
+	assert.Nil(t, err)
+	findings, err = CheckLimits(file, d)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(findings))
+	assert.Equal(t, 2, findings[0].Line)
+}
+
+func TestLimitsOverride(t *testing.T) {
+	l, err := Limits{MaxFileSize: 10, MaxOwnersPerRule: 5}.Override(map[string]int{CheckOwnerCount: 2, CheckFileSize: 0})
+	assert.Nil(t, err)
+	assert.Equal(t, Limits{MaxOwnersPerRule: 2}, l)
+	_, err = Limits{}.Override(map[string]int{"non-existent": 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package verifier
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// normalizePattern returns a canonical form of a CODEOWNERS pattern,
+// so that patterns matching the same paths are equal, e.g. /docs and /docs/
+func normalizePattern(pattern string) string {
+	// As on the matcher, a slash anywhere but the end anchors the pattern to the root,
+	// so it is checked before trimming, e.g. docs/** is anchored while docs/ isn't
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	normalized := pattern
+	for {
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(normalized, "/**"), "/")
+		if trimmed == normalized || trimmed == "" {
+			break
+		}
+		normalized = trimmed
+	}
+	if anchored && !strings.HasPrefix(normalized, "/") {
+		normalized = "/" + normalized
+	}
+	return normalized
+}
+
+// CheckDuplicates returns a Finding for every rule overridden by a later rule
+// with the same or an equivalent pattern on the same section,
+// and for every owner repeated within a rule
+func CheckDuplicates(codeowners []*CodeOwner) []Finding {
+	var findings []Finding
+	for idx, c := range codeowners {
+		for _, later := range codeowners[idx+1:] {
+			if !strings.EqualFold(later.Section, c.Section) {
+				continue
+			}
+			if later.Path == c.Path {
+				findings = append(findings, Finding{
+					Check:    CheckDuplicatePattern,
+					Severity: SeverityWarning,
+					Line:     c.Line,
+					Pattern:  c.Path,
+					Message:  fmt.Sprintf("Line %d: pattern %s is overridden by the same pattern on line %d", c.Line, c.Path, later.Line),
+				})
+				break
+			}
+			if normalizePattern(later.Path) == normalizePattern(c.Path) {
+				findings = append(findings, Finding{
+					Check:    CheckEquivalentPattern,
+					Severity: SeverityWarning,
+					Line:     c.Line,
+					Pattern:  c.Path,
+					Message:  fmt.Sprintf("Line %d: pattern %s is overridden by the equivalent pattern %s on line %d", c.Line, c.Path, later.Path, later.Line),
+				})
+				break
+			}
+		}
+		seen := make(map[string]bool)
+		for _, owner := range c.Owners {
+			if seen[owner] {
+				findings = append(findings, Finding{
+					Check:    CheckDuplicateOwner,
+					Severity: SeverityWarning,
+					Line:     c.Line,
+					Pattern:  c.Path,
+					Owner:    owner,
+					Message:  fmt.Sprintf("Line %d: owner %s is repeated", c.Line, owner),
+				})
+			}
+			seen[owner] = true
+		}
+	}
+	return findings
+}
+
+// FixDuplicates rewrites the CODEOWNERS file removing the rules and owners
+// reported by CheckDuplicates. Overridden rules are removed since only the
+// later rule has any effect. Returns the findings that were fixed
+func FixDuplicates(filename string, d *Dialect) ([]Finding, error) {
+	codeowners, _, err := readCodeowners(filename, d)
+	if err != nil {
+		return nil, err
+	}
+	findings := CheckDuplicates(codeowners)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't open file: %s", err)
+	}
+	byLine := make(map[int]*CodeOwner)
+	for _, c := range codeowners {
+		byLine[c.Line] = c
+	}
+	remove := make(map[int]bool)
+	dedupe := make(map[int]bool)
+	for _, f := range findings {
+		if f.Check == CheckDuplicateOwner {
+			dedupe[f.Line] = true
+			continue
+		}
+		remove[f.Line] = true
+		// the directives of the rule would apply to the next one
+		for _, s := range byLine[f.Line].Suppressions {
+			if !s.FileWide && s.Target == f.Line {
+				remove[s.Line] = true
+			}
+		}
+	}
+	var lines []string
+	for idx, text := range strings.Split(string(content), "\n") {
+		lineNumber := idx + 1
+		if remove[lineNumber] {
+			continue
+		}
+		if dedupe[lineNumber] {
+			text = dedupeOwners(text)
+		}
+		lines = append(lines, text)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "\n")), info.Mode()); err != nil {
+		return nil, fmt.Errorf("Couldn't write file: %s", err)
+	}
+	return findings, nil
+}
+
+// dedupeOwners removes repeated owners from a CODEOWNERS line, keeping its comment
+// and its line ending
+func dedupeOwners(text string) string {
+	ending := ""
+	if strings.HasSuffix(text, "\r") {
+		text, ending = strings.TrimSuffix(text, "\r"), "\r"
+	}
+	entry := stripLineComment(text)
+	comment := strings.TrimPrefix(text, entry)
+	fields := splitFields(entry)
+	seen := make(map[string]bool)
+	result := []string{fields[0]}
+	for _, owner := range fields[1:] {
+		if !seen[owner] {
+			result = append(result, owner)
+		}
+		seen[owner] = true
+	}
+	return strings.Join(result, " ") + comment + ending
+}
@@ -0,0 +1,149 @@
+package verifier
+
+import (
+	"os"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePattern(t *testing.T) {
+	tests := []TestCase{
+		{Name: "trailing slash", Sample: "/docs/", Expected: "/docs"},
+		{Name: "trailing double star", Sample: "/docs/**", Expected: "/docs"},
+		{Name: "anchored by inner slash", Sample: "docs/api/", Expected: "/docs/api"},
+		{Name: "anchored by double star", Sample: "docs/**", Expected: "/docs"},
+		{Name: "unanchored pattern", Sample: "docs", Expected: "docs"},
+		{Name: "unanchored directory", Sample: "docs/", Expected: "docs"},
+		{Name: "root", Sample: "/", Expected: "/"},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected.(string), normalizePattern(test.Sample.(string)))
+	}
+}
+
+func TestNormalizePatternMatchesPath(t *testing.T) {
+	// patterns normalized to the same form must match the same files
+	files := []string{"docs/api/index.md", "src/docs/api/index.md", "docs/index.md", "src/docs/index.md"}
+	for _, patterns := range [][]string{
+		{"docs/api/", "/docs/api/", "/docs/api", "docs/api/**"},
+		{"docs/", "docs"},
+		{"docs/**", "/docs/", "/docs"},
+	} {
+		for _, pattern := range patterns {
+			assert.Equal(t, normalizePattern(patterns[0]), normalizePattern(pattern))
+			expected, _, _ := getPatternFromLine(patterns[0])
+			regex, _, _ := getPatternFromLine(pattern)
+			for _, file := range files {
+				assert.Equal(t, expected.MatchString(file), regex.MatchString(file), "%s and %s on %s", patterns[0], pattern, file)
+			}
+		}
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	defer filet.CleanUp(t)
+	file := filet.TmpFile(t, "", `* @user1
+/docs @user1
+/api/ @a @b @a
+/docs/ @user2
+* @user3
+[Section]
+* @user4
+[Docs]
+/guide/ @user5
+[docs]
+/guide/ @user6`).Name()
+	codeowners, err := ReadCodeownersFile(file)
+	assert.Nil(t, err)
+	findings := CheckDuplicates(codeowners)
+	assert.Equal(t, []Finding{
+		{
+			Check:    CheckDuplicatePattern,
+			Severity: SeverityWarning,
+			Line:     1,
+			Pattern:  "*",
+			Message:  "Line 1: pattern * is overridden by the same pattern on line 5",
+		},
+		{
+			Check:    CheckEquivalentPattern,
+			Severity: SeverityWarning,
+			Line:     2,
+			Pattern:  "/docs",
+			Message:  "Line 2: pattern /docs is overridden by the equivalent pattern /docs/ on line 4",
+		},
+		{
+			Check:    CheckDuplicateOwner,
+			Severity: SeverityWarning,
+			Line:     3,
+			Pattern:  "/api/",
+			Owner:    "@a",
+			Message:  "Line 3: owner @a is repeated",
+		},
+		{
+			Check:    CheckDuplicatePattern,
+			Severity: SeverityWarning,
+			Line:     9,
+			Pattern:  "/guide/",
+			Message:  "Line 9: pattern /guide/ is overridden by the same pattern on line 11",
+		},
+	}, findings)
+}
+
+func TestFixDuplicates(t *testing.T) {
+	defer filet.CleanUp(t)
+	file := filet.TmpFile(t, "", `* @user1
+/docs @user1
+/api/ @a @b @a # api owners
+/docs/ @user2
+* @user3
+`).Name()
+	fixed, err := FixDuplicates(file, dialects[DefaultDialect])
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(fixed))
+	content, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, `/api/ @a @b # api owners
+/docs/ @user2
+* @user3
+`, string(content))
+	codeowners, err := ReadCodeownersFile(file)
+	assert.Nil(t, err)
+	assert.Empty(t, CheckDuplicates(codeowners))
+}
+
+func TestFixDuplicatesRemovesDirectives(t *testing.T) {
+	defer filet.CleanUp(t)
+	file := filet.TmpFile(t, "", `# codeowners-verifier:ignore-file owner-count
+# codeowners-verifier:ignore path-not-found
+/docs @user1
+# codeowners-verifier:ignore owner-not-found
+/api/ @user2
+/docs/ @user3
+`).Name()
+	fixed, err := FixDuplicates(file, dialects[DefaultDialect])
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(fixed))
+	content, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, `# codeowners-verifier:ignore-file owner-count
+# codeowners-verifier:ignore owner-not-found
+/api/ @user2
+/docs/ @user3
+`, string(content))
+}
+
+func TestDedupeOwners(t *testing.T) {
+	tests := []TestCase{
+		{Name: "repeated owner", Sample: "/api/ @a @b @a", Expected: "/api/ @a @b"},
+		{Name: "with comment", Sample: "/api/ @a @a # api owners", Expected: "/api/ @a # api owners"},
+		{Name: "CRLF line ending", Sample: "/api/ @a @a\r", Expected: "/api/ @a\r"},
+		{Name: "CRLF line ending with comment", Sample: "/api/ @a @a # api\r", Expected: "/api/ @a # api\r"},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected.(string), dedupeOwners(test.Sample.(string)))
+	}
+}
@@ -0,0 +1,154 @@
+package verifier
+
+import (
+	"strings"
+)
+
+// Reviewer is an owner suggested to review a change, with the changed files it covers
+type Reviewer struct {
+	Owner string   `json:"owner"`
+	Files []string `json:"files"`
+}
+
+// Reviewers is the set of owners suggested to review a change. Uncovered are
+// the changed files without owners, or without enough owners for their section
+type Reviewers struct {
+	Reviewers []*Reviewer `json:"reviewers"`
+	Uncovered []string    `json:"uncovered"`
+}
+
+// pendingRule tracks the approvals still needed by an entry while suggesting reviewers
+type pendingRule struct {
+	rule     *ApprovalRule
+	owners   []string
+	needed   int
+	selected map[string]bool
+}
+
+// SuggestReviewers returns the smallest set of owners it can find that covers every
+// changed file, picking greedily the owner covering most files. Every entry needs
+// as many reviewers as the approvals of its section, optional sections need none.
+// Owners on ignore, e.g. the author of the change, aren't suggested
+func SuggestReviewers(codeowners []*CodeOwner, sections []*Section, files []string, ignore []string) *Reviewers {
+	ignored := make(map[string]bool)
+	for _, i := range ignore {
+		ignored[strings.TrimPrefix(i, "@")] = true
+	}
+	var candidates []string
+	seen := make(map[string]bool)
+	owned := make(map[string]bool)
+	uncovered := make(map[string]bool)
+	var pending []*pendingRule
+	for _, rule := range RequiredApprovals(codeowners, sections, files) {
+		for _, file := range rule.Files {
+			owned[file] = true
+		}
+		if rule.Section.Optional {
+			continue
+		}
+		p := &pendingRule{rule: rule, needed: rule.Section.Approvals, selected: make(map[string]bool)}
+		for _, owner := range rule.Rule.Owners {
+			if ignored[strings.TrimPrefix(owner, "@")] || contains(p.owners, owner) {
+				continue
+			}
+			p.owners = append(p.owners, owner)
+			if !seen[owner] {
+				seen[owner] = true
+				candidates = append(candidates, owner)
+			}
+		}
+		if len(p.owners) < p.needed {
+			p.needed = len(p.owners)
+			for _, file := range rule.Files {
+				uncovered[file] = true
+			}
+		}
+		pending = append(pending, p)
+	}
+
+	result := &Reviewers{}
+	for {
+		best, bestScore := "", 0
+		for _, owner := range candidates {
+			score := 0
+			for _, p := range pending {
+				if p.needed > 0 && !p.selected[owner] && contains(p.owners, owner) {
+					score += len(p.rule.Files)
+				}
+			}
+			if score > bestScore {
+				best, bestScore = owner, score
+			}
+		}
+		if best == "" {
+			break
+		}
+		reviewer := &Reviewer{Owner: best}
+		for _, file := range files {
+			for _, p := range pending {
+				if p.needed > 0 && !p.selected[best] && contains(p.owners, best) && contains(p.rule.Files, file) {
+					reviewer.Files = append(reviewer.Files, file)
+					break
+				}
+			}
+		}
+		for _, p := range pending {
+			if p.needed > 0 && !p.selected[best] && contains(p.owners, best) {
+				p.selected[best] = true
+				p.needed--
+			}
+		}
+		result.Reviewers = append(result.Reviewers, reviewer)
+	}
+	for _, file := range files {
+		if (!owned[file] || uncovered[file]) && !contains(result.Uncovered, file) {
+			result.Uncovered = append(result.Uncovered, file)
+		}
+	}
+	return result
+}
+
+// contains tells if the value is on the slice
+func contains(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangedFiles returns the files changed by an unified diff, both the old and
+// the new path of renamed files are returned, as both need approval
+func ChangedFiles(diff string) []string {
+	var files []string
+	add := func(file string) {
+		if file != "" && file != "/dev/null" && !contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	header := func(line string) string {
+		file := strings.TrimSpace(line[4:])
+		if cut := strings.Index(file, "\t"); cut >= 0 {
+			file = file[:cut]
+		}
+		if strings.HasPrefix(file, "a/") || strings.HasPrefix(file, "b/") {
+			file = file[2:]
+		}
+		return file
+	}
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		// the file headers come in pairs, telling them from removed lines starting with --
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			add(header(line))
+			add(header(lines[i+1]))
+		case strings.HasPrefix(line, "rename from "):
+			add(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			add(strings.TrimPrefix(line, "rename to "))
+		}
+	}
+	return files
+}
@@ -0,0 +1,95 @@
+package verifier
+
+import (
+	"fmt"
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestReviewers(t *testing.T) {
+	defer filet.CleanUp(t)
+	filename := filet.TmpFile(t, "", `* @user1
+*.md @user2 @docs
+/api/ @user3 @user2
+[Backend][2]
+/api/ @user4 @user5 @user6
+^[Go]
+*.go @user7
+[Legacy][2] @user8
+/legacy/
+`).Name()
+	codeowners, err := ReadCodeownersFile(filename)
+	assert.Nil(t, err)
+	sections, err := ReadSections(filename)
+	assert.Nil(t, err)
+	type sample struct {
+		files  []string
+		ignore []string
+	}
+	tests := []TestCase{
+		{
+			Name:     "single owner covers every file",
+			Sample:   sample{files: []string{"README.md", "docs/index.md"}},
+			Expected: []string{"@user2: [README.md docs/index.md]"},
+		},
+		{
+			Name:     "owner of most files is picked first",
+			Sample:   sample{files: []string{"main.go", "README.md", "api/users.md", "api/handler.py"}},
+			Expected: []string{"@user2: [README.md api/users.md api/handler.py]", "@user4: [api/users.md api/handler.py]", "@user5: [api/users.md api/handler.py]", "@user1: [main.go]"},
+		},
+		{
+			Name:     "ignored owners aren't suggested",
+			Sample:   sample{files: []string{"README.md", "api/handler.py"}, ignore: []string{"@user2", "user4"}},
+			Expected: []string{"@docs: [README.md]", "@user3: [api/handler.py]", "@user5: [api/handler.py]", "@user6: [api/handler.py]"},
+		},
+		{
+			Name:     "files without enough owners are uncovered",
+			Sample:   sample{files: []string{"api/handler.py", "legacy/old.py"}, ignore: []string{"@user5", "@user6", "@user1"}},
+			Expected: []string{"@user3: [api/handler.py]", "@user4: [api/handler.py]", "@user8: [legacy/old.py]", "Uncovered: [api/handler.py legacy/old.py]"},
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		s := test.Sample.(sample)
+		reviewers := SuggestReviewers(codeowners, sections, s.files, s.ignore)
+		var summary []string
+		for _, reviewer := range reviewers.Reviewers {
+			summary = append(summary, fmt.Sprintf("%s: %v", reviewer.Owner, reviewer.Files))
+		}
+		if len(reviewers.Uncovered) > 0 {
+			summary = append(summary, fmt.Sprintf("Uncovered: %v", reviewers.Uncovered))
+		}
+		assert.Equal(t, test.Expected, summary)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	diff := `diff --git a/README.md b/README.md
+index 1111111..2222222 100644
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,2 @@
+ # Title
+--- removed line that looks like a header
++new line
+diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+diff --git a/docs/removed.md b/docs/removed.md
+deleted file mode 100644
+--- a/docs/removed.md
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/added.txt b/added.txt
+new file mode 100644
+--- /dev/null
++++ b/added.txt
+@@ -0,0 +1 @@
++hello
+`
+	assert.Equal(t, []string{"README.md", "old.go", "new.go", "docs/removed.md", "added.txt"}, ChangedFiles(diff))
+}
@@ -0,0 +1,69 @@
+package verifier
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Section represents a GitLab section header, e.g. ^[Documentation][2] @docs-team
+type Section struct {
+	Name string
+	Line int
+	// Optional sections, prefixed by ^, don't require approvals
+	Optional bool
+	// Approvals required from the owners of each entry of the section, defaults to 1
+	Approvals int
+	// Owners are the default owners of the entries written without owners
+	Owners []string
+	// Suppressions are the directive comments written before the header
+	Suppressions []*Suppression
+}
+
+// defaultSection holds the entries written before any section header
+var defaultSection = &Section{Approvals: 1}
+
+// Used to split a section header into optional marker, name, approvals and default owners
+var sectionHeaderRegex = regexp.MustCompile(`^(\^?)\[([^\]]+)\](?:\[(\d+)\])?(.*)$`)
+
+// parseSection returns the section of a header line, or nil if the line isn't a header
+func parseSection(line string, lineNumber int) *Section {
+	match := sectionHeaderRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if match == nil {
+		return nil
+	}
+	section := &Section{
+		Name:      match[2],
+		Line:      lineNumber,
+		Optional:  match[1] == "^",
+		Approvals: 1,
+	}
+	if match[3] != "" {
+		section.Approvals, _ = strconv.Atoi(match[3])
+	}
+	if cut := strings.IndexAny(match[4], commentChars); cut >= 0 {
+		match[4] = match[4][:cut]
+	}
+	section.Owners = splitFields(match[4])
+	return section
+}
+
+// ReadSections returns the section headers of the CODEOWNERS file
+func ReadSections(filename string) ([]*Section, error) {
+	_, sections, err := readCodeowners(filename, dialects[DefaultDialect])
+	return sections, err
+}
+
+// findSection returns the section by name, GitLab combines sections with the same name
+// ignoring case. Entries without section belong to the default section
+func findSection(sections []*Section, name string) *Section {
+	if name == "" {
+		return defaultSection
+	}
+	for _, section := range sections {
+		if strings.EqualFold(section.Name, name) {
+			return section
+		}
+	}
+	return defaultSection
+}
@@ -0,0 +1,62 @@
+package verifier
+
+import (
+	"testing"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSection(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "Checking required section",
+			Sample:   "[Backend]",
+			Expected: &Section{Name: "Backend", Line: 1, Approvals: 1},
+		},
+		{
+			Name:     "Checking optional section with approvals",
+			Sample:   "^[Docs][2]",
+			Expected: &Section{Name: "Docs", Line: 1, Optional: true, Approvals: 2},
+		},
+		{
+			Name:     "Checking section with default owners and comment",
+			Sample:   "[Frontend Team][3] @user1 @group1 # reviewers",
+			Expected: &Section{Name: "Frontend Team", Line: 1, Approvals: 3, Owners: []string{"@user1", "@group1"}},
+		},
+		{
+			Name:     "Checking regular line",
+			Sample:   "*.go @user1",
+			Expected: (*Section)(nil),
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected, parseSection(test.Sample.(string), 1))
+	}
+}
+
+func TestReadSections(t *testing.T) {
+	defer filet.CleanUp(t)
+	filename := filet.TmpFile(t, "", `* @user1
+[Docs] @docs
+*.md
+/docs/ @user2
+^[Optional][2]
+*.go @user3
+`).Name()
+	sections, err := ReadSections(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Section{
+		{Name: "Docs", Line: 2, Approvals: 1, Owners: []string{"@docs"}},
+		{Name: "Optional", Line: 5, Optional: true, Approvals: 2},
+	}, sections)
+	codeowners, err := ReadCodeownersFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"@docs"}, codeowners[1].Owners, "entries without owners use the section default owners")
+	assert.Equal(t, "Docs", codeowners[1].Section)
+	assert.Equal(t, []string{"@user2"}, codeowners[2].Owners)
+
+	_, err = ReadCodeownersFile(filet.TmpFile(t, "", "[Docs]\n*.md\n").Name())
+	assert.Error(t, err, "entries without owners need default owners")
+}
@@ -0,0 +1,120 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+)
+
+// Staleness represents how recently the owners of an entry committed to the files it matches
+type Staleness struct {
+	Section *Section
+	Rule    *CodeOwner
+	Files   []string
+	// LastOwnerCommit is the date of the last commit of an owner on the files, zero when they never did
+	LastOwnerCommit time.Time
+	// Authors are the usernames that committed to the files after the cutoff
+	Authors []string
+	// Stale tells none of the owners committed to the files after the cutoff
+	Stale bool
+}
+
+// CheckStaleness compares the owners of every entry with the authors of the commits
+// on the files it matches, using the last matching entry of every section like
+// RequiredApprovals. Authors are mapped to usernames with authors, and count for
+// an entry when they're one of its owners or isMember tells they belong to one of them.
+// Authors without a username are only compared with the owners, since providers
+// can't tell the groups of an email
+func CheckStaleness(codeowners []*CodeOwner, sections []*Section, files []string, commits []*git.Commit, authors git.Authors, cutoff time.Time, isMember MemberChecker) ([]*Staleness, error) {
+	var report []*Staleness
+	byFile := make(map[string][]*Staleness)
+	for _, rule := range RequiredApprovals(codeowners, sections, files) {
+		s := &Staleness{Section: rule.Section, Rule: rule.Rule, Files: rule.Files}
+		for _, file := range rule.Files {
+			byFile[file] = append(byFile[file], s)
+		}
+		report = append(report, s)
+	}
+	members := make(map[string]bool)
+	isOwner := func(username string, mapped bool, rule *CodeOwner) (bool, error) {
+		for _, element := range rule.Owners {
+			owner := strings.TrimPrefix(element, "@")
+			user := strings.TrimPrefix(username, "@")
+			if strings.EqualFold(owner, user) {
+				return true, nil
+			}
+			if isMember == nil || !mapped {
+				continue
+			}
+			key := user + " " + owner
+			member, ok := members[key]
+			if !ok {
+				var err error
+				if member, err = isMember(user, owner); err != nil {
+					return false, err
+				}
+				members[key] = member
+			}
+			if member {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for _, commit := range commits {
+		username, mapped := authors.Lookup(commit.AuthorName, commit.AuthorEmail)
+		seen := make(map[*Staleness]bool)
+		for _, file := range commit.Files {
+			for _, s := range byFile[file.Path] {
+				if seen[s] {
+					continue
+				}
+				seen[s] = true
+				owner, err := isOwner(username, mapped, s.Rule)
+				if err != nil {
+					return nil, err
+				}
+				if owner && commit.Date.After(s.LastOwnerCommit) {
+					s.LastOwnerCommit = commit.Date
+				}
+				if commit.Date.After(cutoff) && !contains(s.Authors, username) {
+					s.Authors = append(s.Authors, username)
+				}
+			}
+		}
+	}
+	for _, s := range report {
+		sort.Strings(s.Authors)
+		s.Stale = !s.LastOwnerCommit.After(cutoff)
+	}
+	return report, nil
+}
+
+// StalenessFindings returns a warning for every stale entry
+func StalenessFindings(report []*Staleness) []Finding {
+	var findings []Finding
+	for _, s := range report {
+		if !s.Stale {
+			continue
+		}
+		last := "never committed to them"
+		if !s.LastOwnerCommit.IsZero() {
+			last = "last committed on " + s.LastOwnerCommit.Format("2006-01-02")
+		}
+		recent := "no recent commits"
+		if len(s.Authors) > 0 {
+			recent = "recent authors: " + strings.Join(s.Authors, ", ")
+		}
+		findings = append(findings, Finding{
+			Check:    CheckStaleOwners,
+			Severity: SeverityWarning,
+			Line:     s.Rule.Line,
+			Pattern:  s.Rule.Path,
+			Message:  fmt.Sprintf("Line %d: owners %s of %s (%d files) %s, %s", s.Rule.Line, s.Rule.Owners, s.Rule.Path, len(s.Files), last, recent),
+		})
+	}
+	return findings
+}
@@ -0,0 +1,84 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	filet "github.com/Flaque/filet"
+	"github.com/stretchr/testify/assert"
+	"github.com/topfreegames/codeowners-verifier/pkg/git"
+)
+
+func TestCheckStaleness(t *testing.T) {
+	defer filet.CleanUp(t)
+	filename := filet.TmpFile(t, "", `* @alice
+/api/ @bob @backend
+/docs/ @carol
+/web/ @dave
+`).Name()
+	codeowners, err := ReadCodeownersFile(filename)
+	assert.Nil(t, err)
+	sections, err := ReadSections(filename)
+	assert.Nil(t, err)
+	files := []string{"README.md", "api/handler.go", "docs/index.md", "web/app.js"}
+	date := func(value string) time.Time {
+		d, _ := time.Parse("2006-01-02", value)
+		return d
+	}
+	commits := []*git.Commit{
+		{AuthorName: "Alice", AuthorEmail: "alice@example.com", Date: date("2023-06-01"), Files: []git.FileChange{{Path: "README.md"}}},
+		{AuthorName: "Erin", AuthorEmail: "erin@example.com", Date: date("2023-05-01"), Files: []git.FileChange{{Path: "api/handler.go"}}},
+		{AuthorName: "Carol", AuthorEmail: "carol@example.com", Date: date("2022-01-01"), Files: []git.FileChange{{Path: "docs/index.md"}}},
+		{AuthorName: "Frank", AuthorEmail: "frank@example.com", Date: date("2023-04-01"), Files: []git.FileChange{{Path: "docs/index.md"}, {Path: "removed.go"}}},
+	}
+	authors := git.Authors{"alice@example.com": "alice", "Carol": "carol", "erin@example.com": "erin"}
+	isMember := func(username string, owner string) (bool, error) {
+		if strings.Contains(username, "@") {
+			return false, fmt.Errorf("unmapped author %s looked up on the provider", username)
+		}
+		return username == "erin" && owner == "backend", nil
+	}
+	cutoff := date("2023-01-01")
+
+	report, err := CheckStaleness(codeowners, sections, files, commits, authors, cutoff, isMember)
+	assert.Nil(t, err)
+	var summary []string
+	for _, s := range report {
+		summary = append(summary, fmt.Sprintf("%s %v %s %v", s.Rule.Path, s.Stale, s.LastOwnerCommit.Format("2006-01-02"), s.Authors))
+	}
+	assert.Equal(t, []string{
+		"* false 2023-06-01 [@alice]",
+		"/api/ false 2023-05-01 [@erin]",
+		"/docs/ true 2022-01-01 [frank@example.com]",
+		"/web/ true 0001-01-01 []",
+	}, summary)
+
+	findings := StalenessFindings(report)
+	assert.Equal(t, []Finding{
+		{
+			Check:    CheckStaleOwners,
+			Severity: SeverityWarning,
+			Line:     3,
+			Pattern:  "/docs/",
+			Message:  "Line 3: owners [@carol] of /docs/ (1 files) last committed on 2022-01-01, recent authors: frank@example.com",
+		},
+		{
+			Check:    CheckStaleOwners,
+			Severity: SeverityWarning,
+			Line:     4,
+			Pattern:  "/web/",
+			Message:  "Line 4: owners [@dave] of /web/ (1 files) never committed to them, no recent commits",
+		},
+	}, findings)
+
+	report, err = CheckStaleness(codeowners, sections, files, commits, authors, cutoff, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, true, report[1].Stale)
+
+	_, err = CheckStaleness(codeowners, sections, files, commits, authors, cutoff, func(username string, owner string) (bool, error) {
+		return false, fmt.Errorf("connection refused")
+	})
+	assert.Equal(t, fmt.Errorf("connection refused"), err)
+}
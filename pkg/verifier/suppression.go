@@ -0,0 +1,154 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Directives recognized on CODEOWNERS comments
+const (
+	// suppressDirective suppresses findings on the next rule
+	suppressDirective = "codeowners-verifier:ignore"
+	// suppressFileDirective suppresses findings on the whole file
+	suppressFileDirective = "codeowners-verifier:ignore-file"
+)
+
+// Suppression represents a directive comment that suppresses findings
+type Suppression struct {
+	// Line where the directive comment is
+	Line int
+	// Target is the line of the rule the directive applies to
+	Target int
+	// FileWide is true when the directive applies to the whole file
+	FileWide bool
+	// Checks suppressed by the directive, empty means every check
+	Checks []string
+	// Unknown are the names on the directive that aren't checks, they suppress nothing
+	Unknown []string
+	// Reason is the text written after the checks
+	Reason string
+	used   bool
+}
+
+// parseSuppression returns the directive of a comment line, or nil if the line isn't a directive, e.g.
+//
+//	# codeowners-verifier:ignore path-not-found released on the next deploy
+//	/release-only/ @group1
+//	# codeowners-verifier:ignore-file duplicate-owner,owner-count
+//
+// The checks are the comma separated list after the directive, the rest of the line is the reason
+func parseSuppression(text string, lineNumber int) *Suppression {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "#") {
+		return nil
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, "#"))
+	if len(fields) == 0 {
+		return nil
+	}
+	s := &Suppression{Line: lineNumber}
+	switch fields[0] {
+	case suppressDirective:
+	case suppressFileDirective:
+		s.FileWide = true
+	default:
+		return nil
+	}
+	// the list goes on while it ends with a comma, e.g. owner-not-found, duplicate-owner
+	idx := 1
+	for ; idx < len(fields); idx++ {
+		for _, name := range strings.Split(fields[idx], ",") {
+			switch {
+			case name == "":
+			case knownCheck(name):
+				s.Checks = append(s.Checks, name)
+			default:
+				s.Unknown = append(s.Unknown, name)
+			}
+		}
+		if !strings.HasSuffix(fields[idx], ",") {
+			idx++
+			break
+		}
+	}
+	if idx < len(fields) {
+		s.Reason = strings.Join(fields[idx:], " ")
+	}
+	return s
+}
+
+// Suppressions returns the directives attached to the entries and sections while
+// parsing the CODEOWNERS file, ordered by line
+func Suppressions(codeowners []*CodeOwner, sections []*Section) []*Suppression {
+	var suppressions []*Suppression
+	for _, c := range codeowners {
+		suppressions = append(suppressions, c.Suppressions...)
+	}
+	for _, section := range sections {
+		suppressions = append(suppressions, section.Suppressions...)
+	}
+	sort.Slice(suppressions, func(i, j int) bool { return suppressions[i].Line < suppressions[j].Line })
+	return suppressions
+}
+
+// matches returns true if the suppression applies to the finding
+func (s *Suppression) matches(f Finding) bool {
+	if !s.FileWide && (s.Target == 0 || s.Target != f.Line) {
+		return false
+	}
+	if len(s.Checks) == 0 {
+		// a directive naming only unknown checks suppresses nothing
+		return len(s.Unknown) == 0
+	}
+	for _, check := range s.Checks {
+		if check == f.Check {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplySuppressions returns the findings not suppressed by any directive,
+// plus a finding for every directive that didn't suppress anything
+func ApplySuppressions(findings []Finding, suppressions []*Suppression) []Finding {
+	var remaining []Finding
+	for _, f := range findings {
+		suppressed := false
+		for _, s := range suppressions {
+			if s.matches(f) {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			remaining = append(remaining, f)
+		}
+	}
+	for _, s := range suppressions {
+		if len(s.Unknown) > 0 {
+			remaining = append(remaining, Finding{
+				Check:    CheckInvalidSuppression,
+				Severity: SeverityWarning,
+				Line:     s.Line,
+				Message:  fmt.Sprintf("Line %d: suppression of unknown checks %s, valid checks: %v", s.Line, strings.Join(s.Unknown, ","), checks),
+			})
+			if len(s.Checks) == 0 {
+				continue
+			}
+		}
+		if !s.used {
+			suppressed := "all checks"
+			if len(s.Checks) > 0 {
+				suppressed = strings.Join(s.Checks, ",")
+			}
+			remaining = append(remaining, Finding{
+				Check:    CheckUnusedSuppression,
+				Severity: SeverityWarning,
+				Line:     s.Line,
+				Message:  fmt.Sprintf("Line %d: suppression of %s doesn't match any finding", s.Line, suppressed),
+			})
+		}
+	}
+	return remaining
+}
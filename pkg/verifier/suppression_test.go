@@ -0,0 +1,107 @@
+package verifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppressions(t *testing.T) {
+	codeowners, sections, err := ParseCodeowners(strings.NewReader(`# codeowners-verifier:ignore-file owner-count
+* @user1
+# codeowners-verifier:ignore path-not-found
+# codeowners-verifier:ignore owner-not-found, duplicate-owner
+
+/release/ @user2
+# codeowners-verifier:ignore section-count
+[Docs]
+/docs/ @user3
+# regular comment
+# codeowners-verifier:ignore
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Suppression{{Line: 1, FileWide: true, Checks: []string{CheckOwnerCount}}}, codeowners[0].Suppressions)
+	assert.Equal(t, 2, len(codeowners[1].Suppressions))
+	assert.Equal(t, []*Suppression{{Line: 7, Target: 8, Checks: []string{CheckSectionCount}}}, sections[0].Suppressions)
+	assert.Equal(t, []*Suppression{
+		{Line: 1, FileWide: true, Checks: []string{CheckOwnerCount}},
+		{Line: 3, Target: 6, Checks: []string{CheckPathNotFound}},
+		{Line: 4, Target: 6, Checks: []string{CheckOwnerNotFound, CheckDuplicateOwner}},
+		{Line: 7, Target: 8, Checks: []string{CheckSectionCount}},
+		{Line: 11},
+	}, Suppressions(codeowners, sections))
+}
+
+func TestParseSuppression(t *testing.T) {
+	tests := []TestCase{
+		{
+			Name:     "checks and reason",
+			Sample:   "# codeowners-verifier:ignore owner-not-found legacy team",
+			Expected: &Suppression{Line: 1, Checks: []string{CheckOwnerNotFound}, Reason: "legacy team"},
+		},
+		{
+			Name:     "comma separated checks with spaces",
+			Sample:   "# codeowners-verifier:ignore owner-not-found, duplicate-owner moved soon",
+			Expected: &Suppression{Line: 1, Checks: []string{CheckOwnerNotFound, CheckDuplicateOwner}, Reason: "moved soon"},
+		},
+		{
+			Name:     "unknown check",
+			Sample:   "# codeowners-verifier:ignore-file owner-exists,policy:no-sole-individual",
+			Expected: &Suppression{Line: 1, FileWide: true, Checks: []string{"policy:no-sole-individual"}, Unknown: []string{"owner-exists"}},
+		},
+		{
+			Name:     "regular comment",
+			Sample:   "# owner-not-found",
+			Expected: (*Suppression)(nil),
+		},
+	}
+	for i, test := range tests {
+		t.Logf("Test case %d: %s", i, test.Name)
+		assert.Equal(t, test.Expected.(*Suppression), parseSuppression(test.Sample.(string), 1))
+	}
+}
+
+func TestApplyUnknownSuppressions(t *testing.T) {
+	suppressions := []*Suppression{
+		{Line: 1, Target: 2, Unknown: []string{"owner-exists"}},
+		{Line: 3, Target: 4, Checks: []string{CheckPathNotFound}, Unknown: []string{"path-exists"}},
+	}
+	findings := []Finding{
+		{Check: CheckOwnerNotFound, Line: 2},
+		{Check: CheckPathNotFound, Line: 4},
+	}
+	remaining := ApplySuppressions(findings, suppressions)
+	assert.Equal(t, 3, len(remaining))
+	assert.Equal(t, Finding{Check: CheckOwnerNotFound, Line: 2}, remaining[0], "unknown checks suppress nothing")
+	assert.Equal(t, CheckInvalidSuppression, remaining[1].Check)
+	assert.Equal(t, 1, remaining[1].Line)
+	assert.Equal(t, CheckInvalidSuppression, remaining[2].Check)
+	assert.Equal(t, 3, remaining[2].Line)
+}
+
+func TestApplySuppressions(t *testing.T) {
+	suppressions := []*Suppression{
+		{Line: 1, FileWide: true, Checks: []string{CheckFileSize}},
+		{Line: 3, Target: 4, Checks: []string{CheckPathNotFound}},
+		{Line: 5, Target: 6},
+		{Line: 7, Target: 8, Checks: []string{CheckOwnerNotFound}},
+	}
+	findings := []Finding{
+		{Check: CheckFileSize},
+		{Check: CheckPathNotFound, Line: 4},
+		{Check: CheckOwnerNotFound, Line: 4},
+		{Check: CheckOwnerNotFound, Line: 6},
+		{Check: CheckPathNotFound, Line: 6},
+	}
+	remaining := ApplySuppressions(findings, suppressions)
+	assert.Equal(t, []Finding{
+		{Check: CheckOwnerNotFound, Line: 4},
+		{
+			Check:    CheckUnusedSuppression,
+			Severity: SeverityWarning,
+			Line:     7,
+			Message:  "Line 7: suppression of owner-not-found doesn't match any finding",
+		},
+	}, remaining)
+}